@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pdat-cz/go-mbus"
+)
+
+func main() {
+	// Parse command-line arguments
+	port := flag.String("port", "/dev/ttyUSB0", "Serial port or tcp://host:port of a level converter connected to M-Bus")
+	planFile := flag.String("plan", "", "YAML file with the planned primary addresses (optional)")
+	first := flag.Int("first", 1, "First primary address for meters that are not in the plan")
+	output := flag.String("output", "inventory.yaml", "Inventory file to write")
+	dryRun := flag.Bool("dry-run", false, "Only search and read the meters, do not change addresses")
+	flag.Parse()
+
+	plan := mbus.CommissionPlan{}
+	if *planFile != "" {
+		f, err := os.Open(*planFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening plan: %s\n", err)
+			os.Exit(1)
+		}
+		plan, err = mbus.ReadCommissionPlan(f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading plan: %s\n", err)
+			os.Exit(1)
+		}
+	}
+	if plan.First == 0 {
+		plan.First = *first
+	}
+	plan.DryRun = plan.DryRun || *dryRun
+
+	fmt.Printf("Commissioning M-Bus meters on port %s...\n", *port)
+	inventory, err := mbus.Commission(*port, plan)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error commissioning meters: %s\n", err)
+		os.Exit(1)
+	}
+
+	failed := 0
+	for _, meter := range inventory.Meters {
+		if meter.Error != "" {
+			failed++
+			fmt.Printf("%s: error: %s\n", meter.SecondaryAddress, meter.Error)
+			continue
+		}
+		fmt.Printf("%s: %s %s, address %d -> %d\n",
+			meter.SecondaryAddress, meter.Manufacturer, meter.Medium, meter.PreviousAddress, meter.PrimaryAddress)
+	}
+	for _, collision := range inventory.Collisions {
+		fmt.Printf("Collision: several meters answer to %s, commission them by hand\n", collision)
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating inventory: %s\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+	if err := inventory.WriteYAML(f); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing inventory: %s\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Commissioning complete. %d meters, %d failed. Inventory written to %s\n",
+		len(inventory.Meters), failed, *output)
+}
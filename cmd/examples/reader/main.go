@@ -11,14 +11,26 @@ import (
 
 func main() {
 	// Parse command-line arguments
-	port := flag.String("port", "/dev/ttyUSB0", "Serial port connected to M-Bus")
+	port := flag.String("port", "/dev/ttyUSB0", "Serial port or tcp://host:port of a level converter connected to M-Bus")
 	address := flag.Int("address", 1, "M-Bus device address")
+	secondary := flag.String("secondary", "", "Secondary address of the device (16 HEX digits, F is a wildcard), used instead of -address")
 	outputFormat := flag.String("format", "json", "Output format (json or text)")
 	flag.Parse()
 
 	// Read data from the device
-	fmt.Printf("Reading M-Bus device at address %d on port %s...\n", *address, *port)
-	deviceState := mbus.Read(*port, *address)
+	var deviceState mbus.DeviceState
+	if *secondary != "" {
+		secondaryAddress, err := mbus.ParseSecondaryAddress(*secondary)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Reading M-Bus device with secondary address %s on port %s...\n", secondaryAddress, *port)
+		deviceState = mbus.ReadSecondary(*port, secondaryAddress)
+	} else {
+		fmt.Printf("Reading M-Bus device at address %d on port %s...\n", *address, *port)
+		deviceState = mbus.Read(*port, *address)
+	}
 
 	// Check for errors
 	if deviceState.Error != "" {
@@ -40,6 +52,9 @@ func main() {
 		fmt.Printf("Device State:\n")
 		fmt.Printf("  Port: %s\n", deviceState.Port)
 		fmt.Printf("  Address: %d\n", deviceState.Address)
+		if deviceState.SecondaryAddress != "" {
+			fmt.Printf("  Secondary Address: %s\n", deviceState.SecondaryAddress)
+		}
 		fmt.Printf("  Timestamp: %s\n", deviceState.Timestamp.Format("2006-01-02 15:04:05"))
 
 		if len(deviceState.Data.Records) > 0 {
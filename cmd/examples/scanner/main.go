@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -12,14 +14,21 @@ import (
 
 func main() {
 	// Parse command-line arguments
-	port := flag.String("port", "/dev/ttyUSB0", "Serial port connected to M-Bus")
+	port := flag.String("port", "/dev/ttyUSB0", "Serial port or tcp://host:port of a level converter connected to M-Bus")
 	startAddr := flag.Int("start", 1, "Start address for scanning")
 	endAddr := flag.Int("end", 250, "End address for scanning")
 	concurrent := flag.Int("concurrent", 1, "Number of concurrent scans")
 	timeout := flag.Duration("timeout", 5*time.Second, "Timeout for each device scan")
 	readData := flag.Bool("read", false, "Read data from found devices")
+	secondary := flag.Bool("secondary", false, "Search devices by secondary address instead of pinging primary addresses")
+	multiBaud := flag.Bool("multibaud", false, "Ping every address at each standard baud rate (300-38400) to find devices at other rates than 2400")
 	flag.Parse()
 
+	if *secondary {
+		scanSecondary(*port, *readData)
+		return
+	}
+
 	// Validate input
 	if *startAddr < 1 || *startAddr > 250 {
 		fmt.Fprintf(os.Stderr, "Start address must be between 1 and 250\n")
@@ -34,6 +43,11 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *multiBaud {
+		scanMultiBaud(*port, *startAddr, *endAddr, *readData)
+		return
+	}
+
 	fmt.Printf("Scanning M-Bus devices on port %s (addresses %d-%d)...\n",
 		*port, *startAddr, *endAddr)
 
@@ -41,7 +55,7 @@ func main() {
 	addresses := make(chan int, *endAddr-*startAddr+1)
 
 	// Create a channel for results
-	results := make(chan int, *endAddr-*startAddr+1)
+	results := make(chan mbus.PingState, *endAddr-*startAddr+1)
 
 	// Create a wait group to wait for all goroutines to finish
 	var wg sync.WaitGroup
@@ -52,23 +66,16 @@ func main() {
 		go func() {
 			defer wg.Done()
 			for addr := range addresses {
-				// Set a timeout for the scan
-				done := make(chan bool, 1)
-				go func() {
-					pingState := mbus.Ping(*port, addr)
-					if pingState.State {
-						results <- addr
-					}
-					done <- true
-				}()
-
-				// Wait for the scan to complete or timeout
-				select {
-				case <-done:
-					// Scan completed
-				case <-time.After(*timeout):
+				// The ping stops and releases the port when the timeout is reached
+				ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+				pingState := mbus.PingContext(ctx, *port, addr)
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
 					fmt.Printf("Timeout scanning address %d\n", addr)
 				}
+				cancel()
+				if pingState.State || pingState.Collision {
+					results <- pingState
+				}
 			}
 		}()
 	}
@@ -89,12 +96,21 @@ func main() {
 
 	// Collect and display results
 	foundDevices := []int{}
-	for addr := range results {
-		foundDevices = append(foundDevices, addr)
-		fmt.Printf("Found device at address %d\n", addr)
+	collisions := []int{}
+	for pingState := range results {
+		if pingState.Collision {
+			collisions = append(collisions, pingState.Address)
+			fmt.Printf("Collision at address %d: several devices answered\n", pingState.Address)
+			continue
+		}
+		foundDevices = append(foundDevices, pingState.Address)
+		fmt.Printf("Found device at address %d\n", pingState.Address)
 	}
 
 	fmt.Printf("Scan complete. Found %d devices.\n", len(foundDevices))
+	if len(collisions) > 0 {
+		fmt.Printf("Address collisions at %v. Search by secondary address (-secondary) to tell the devices apart.\n", collisions)
+	}
 
 	// Read data from found devices if requested
 	if *readData && len(foundDevices) > 0 {
@@ -108,22 +124,112 @@ func main() {
 				continue
 			}
 
+			printDeviceState(deviceState)
+		}
+	}
+}
+
+// scanSecondary searches all devices by secondary address, including devices at primary address 0
+func scanSecondary(port string, readData bool) {
+	fmt.Printf("Searching M-Bus devices by secondary address on port %s...\n", port)
+	result, err := mbus.SearchSecondary(port)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error searching devices: %s\n", err)
+		os.Exit(1)
+	}
+
+	for _, address := range result.Found {
+		fmt.Printf("Found device %s (manufacturer %s, version %d, medium %s)\n",
+			address, address.Manufacturer, address.Version, address.Medium)
+	}
+	for _, address := range result.Collisions {
+		fmt.Printf("Collision: several devices answer to %s\n", address)
+	}
+	fmt.Printf("Search complete. Found %d devices.\n", len(result.Found))
+
+	if readData && len(result.Found) > 0 {
+		fmt.Println("\nReading data from found devices:")
+		for _, address := range result.Found {
+			fmt.Printf("\nReading device %s...\n", address)
+			deviceState := mbus.ReadSecondary(port, address)
+
+			if deviceState.Error != "" {
+				fmt.Printf("Error reading device: %s\n", deviceState.Error)
+				continue
+			}
+
+			printDeviceState(deviceState)
+		}
+	}
+}
+
+// scanMultiBaud pings the addresses at every standard baud rate. The bus remembers the
+// rate of each device, so the data is read at the rate the device answered at.
+func scanMultiBaud(port string, startAddr int, endAddr int, readData bool) {
+	fmt.Printf("Scanning M-Bus devices on port %s (addresses %d-%d) at all baud rates...\n",
+		port, startAddr, endAddr)
+	bus, err := mbus.OpenBus(port)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening port: %s\n", err)
+		os.Exit(1)
+	}
+	defer bus.Close()
+
+	found, err := bus.ScanBaudRates(startAddr, endAddr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error scanning devices: %s\n", err)
+		os.Exit(1)
+	}
+
+	foundDevices := []int{}
+	for _, pingState := range found {
+		if pingState.Collision {
+			fmt.Printf("Collision at address %d (%d baud): several devices answered\n", pingState.Address, pingState.BaudRate)
+			continue
+		}
+		foundDevices = append(foundDevices, pingState.Address)
+		fmt.Printf("Found device at address %d (%d baud)\n", pingState.Address, pingState.BaudRate)
+	}
+	fmt.Printf("Scan complete. Found %d devices.\n", len(foundDevices))
+
+	if readData && len(foundDevices) > 0 {
+		fmt.Println("\nReading data from found devices:")
+		for _, addr := range foundDevices {
+			fmt.Printf("\nReading device at address %d...\n", addr)
+			data, err := bus.ReadUD2(addr)
+			if err != nil {
+				fmt.Printf("Error reading device: %s\n", err)
+				continue
+			}
+
 			fmt.Printf("Device data:\n")
-			fmt.Printf("  Port: %s\n", deviceState.Port)
-			fmt.Printf("  Address: %d\n", deviceState.Address)
-			fmt.Printf("  Timestamp: %s\n", deviceState.Timestamp.Format("2006-01-02 15:04:05"))
-
-			if len(deviceState.Data.Records) > 0 {
-				fmt.Printf("  Data Records:\n")
-				for i, record := range deviceState.Data.Records {
-					fmt.Printf("    Record %d:\n", i+1)
-					fmt.Printf("      Description: %s\n", record.Description)
-					fmt.Printf("      Value: %s\n", record.Value)
-					fmt.Printf("      Unit: %s\n", record.Unit)
-				}
-			} else {
-				fmt.Printf("  No data records available\n")
+			fmt.Printf("  Identification Number: %s\n", data.IdentificationNumber)
+			fmt.Printf("  Manufacturer: %s\n", data.Manufacturer)
+			for i, record := range data.Records {
+				fmt.Printf("    Record %d: %s = %s %s\n", i+1, record.Name, record.Value, record.Unit)
 			}
 		}
 	}
 }
+
+func printDeviceState(deviceState mbus.DeviceState) {
+	fmt.Printf("Device data:\n")
+	fmt.Printf("  Port: %s\n", deviceState.Port)
+	fmt.Printf("  Address: %d\n", deviceState.Address)
+	if deviceState.SecondaryAddress != "" {
+		fmt.Printf("  Secondary Address: %s\n", deviceState.SecondaryAddress)
+	}
+	fmt.Printf("  Timestamp: %s\n", deviceState.Timestamp.Format("2006-01-02 15:04:05"))
+
+	if len(deviceState.Data.Records) > 0 {
+		fmt.Printf("  Data Records:\n")
+		for i, record := range deviceState.Data.Records {
+			fmt.Printf("    Record %d:\n", i+1)
+			fmt.Printf("      Description: %s\n", record.Description)
+			fmt.Printf("      Value: %s\n", record.Value)
+			fmt.Printf("      Unit: %s\n", record.Unit)
+		}
+	} else {
+		fmt.Printf("  No data records available\n")
+	}
+}
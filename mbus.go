@@ -5,6 +5,8 @@
 package mbus
 
 import (
+	"context"
+	"io"
 	"time"
 
 	"github.com/pdat-cz/go-mbus/pkg/mbus"
@@ -15,33 +17,416 @@ func Ping(port string, address int) mbus.PingState {
 	return mbus.Ping(port, address)
 }
 
+// PingWith checks if a device is alive at the given address using an already open transport.
+func PingWith(t Transport, address int) mbus.PingState {
+	return mbus.PingWith(t, address)
+}
+
+// PingContext checks if a device is alive at the given address and stops waiting when the context is done.
+func PingContext(ctx context.Context, port string, address int) mbus.PingState {
+	return mbus.PingContext(ctx, port, address)
+}
+
+// PingWithContext checks if a device is alive using an already open transport and stops waiting when the context is done.
+func PingWithContext(ctx context.Context, t Transport, address int) mbus.PingState {
+	return mbus.PingWithContext(ctx, t, address)
+}
+
 // Read reads data from a device at the given address.
 func Read(port string, address int) DeviceState {
-	ds := mbus.Read(port, address)
-
-	// Convert to our DeviceState with our LFrameRecord
-	result := DeviceState{
-		Port:      ds.Port,
-		Address:   ds.Address,
-		Timestamp: ds.Timestamp,
-		Error:     ds.Error,
-		Data: LFrameParsed{
-			IdentificationNumber: ds.Data.IdentificationNumber,
-			Manufacturer:         ds.Data.Manufacturer,
-			Version:              ds.Data.Version,
-			Medium:               ds.Data.Medium,
-			AccessNumber:         ds.Data.AccessNumber,
-			Status:               ds.Data.Status,
-			Model:                ds.Data.Model,
-			Address:              ds.Data.Address,
-			Signature:            ds.Data.Signature,
-			Records:              make(map[int]LFrameRecord),
-		},
+	return convertDeviceState(mbus.Read(port, address))
+}
+
+// ReadWith reads data from a device at the given address using an already open transport.
+func ReadWith(t Transport, address int) DeviceState {
+	return convertDeviceState(mbus.ReadWith(t, address))
+}
+
+// ReadContext reads data from a device at the given address and stops waiting when the context is done.
+func ReadContext(ctx context.Context, port string, address int) DeviceState {
+	return convertDeviceState(mbus.ReadContext(ctx, port, address))
+}
+
+// ReadWithContext reads data from a device using an already open transport and stops waiting when the context is done.
+func ReadWithContext(ctx context.Context, t Transport, address int) DeviceState {
+	return convertDeviceState(mbus.ReadWithContext(ctx, t, address))
+}
+
+// ReadSelected sends the record selectors to the device and reads only the selected records.
+func ReadSelected(port string, address int, selectors []RecordSelector) DeviceState {
+	return convertDeviceState(mbus.ReadSelected(port, address, selectors))
+}
+
+// ReadSelectedWith sends the record selectors and reads the data using an already open transport.
+func ReadSelectedWith(t Transport, address int, selectors []RecordSelector) DeviceState {
+	return convertDeviceState(mbus.ReadSelectedWith(t, address, selectors))
+}
+
+// ReadSelectedContext is ReadSelected that stops waiting when the context is done.
+func ReadSelectedContext(ctx context.Context, port string, address int, selectors []RecordSelector) DeviceState {
+	return convertDeviceState(mbus.ReadSelectedContext(ctx, port, address, selectors))
+}
+
+// ReadSelectedWithContext is ReadSelectedWith that stops waiting when the context is done.
+func ReadSelectedWithContext(ctx context.Context, t Transport, address int, selectors []RecordSelector) DeviceState {
+	return convertDeviceState(mbus.ReadSelectedWithContext(ctx, t, address, selectors))
+}
+
+// ReadSecondary selects the device by its secondary address and reads its data.
+func ReadSecondary(port string, address SecondaryAddress) DeviceState {
+	return convertDeviceState(mbus.ReadSecondary(port, address))
+}
+
+// ReadSecondaryWith selects the device by its secondary address and reads its data using an already open transport.
+func ReadSecondaryWith(t Transport, address SecondaryAddress) DeviceState {
+	return convertDeviceState(mbus.ReadSecondaryWith(t, address))
+}
+
+// ReadSecondaryContext is ReadSecondary that stops waiting when the context is done.
+func ReadSecondaryContext(ctx context.Context, port string, address SecondaryAddress) DeviceState {
+	return convertDeviceState(mbus.ReadSecondaryContext(ctx, port, address))
+}
+
+// ReadSecondaryWithContext is ReadSecondaryWith that stops waiting when the context is done.
+func ReadSecondaryWithContext(ctx context.Context, t Transport, address SecondaryAddress) DeviceState {
+	return convertDeviceState(mbus.ReadSecondaryWithContext(ctx, t, address))
+}
+
+// ParseSecondaryAddress parses the 16 HEX digit form of a secondary address, e.g. "12345678FFFFFFFF".
+func ParseSecondaryAddress(s string) (SecondaryAddress, error) {
+	return mbus.ParseSecondaryAddress(s)
+}
+
+// SearchSecondary finds all devices on the port by their secondary address.
+func SearchSecondary(port string) (SecondarySearch, error) {
+	return mbus.SearchSecondary(port)
+}
+
+// SearchSecondaryWith finds all devices by their secondary address using an already open transport.
+func SearchSecondaryWith(t Transport) (SecondarySearch, error) {
+	return mbus.SearchSecondaryWith(t)
+}
+
+// SearchSecondaryContext is SearchSecondary that stops waiting when the context is done.
+func SearchSecondaryContext(ctx context.Context, port string) (SecondarySearch, error) {
+	return mbus.SearchSecondaryContext(ctx, port)
+}
+
+// SearchSecondaryWithContext is SearchSecondaryWith that stops waiting when the context is done.
+func SearchSecondaryWithContext(ctx context.Context, t Transport) (SecondarySearch, error) {
+	return mbus.SearchSecondaryWithContext(ctx, t)
+}
+
+// SetPrimaryAddress changes the primary address of the device and checks that it answers at the new address.
+func SetPrimaryAddress(port string, address int, newAddress int) error {
+	return mbus.SetPrimaryAddress(port, address, newAddress)
+}
+
+// SetPrimaryAddressWith changes the primary address of the device using an already open transport.
+func SetPrimaryAddressWith(t Transport, address int, newAddress int) error {
+	return mbus.SetPrimaryAddressWith(t, address, newAddress)
+}
+
+// SetPrimaryAddressContext is SetPrimaryAddress that stops waiting when the context is done.
+func SetPrimaryAddressContext(ctx context.Context, port string, address int, newAddress int) error {
+	return mbus.SetPrimaryAddressContext(ctx, port, address, newAddress)
+}
+
+// SetPrimaryAddressWithContext is SetPrimaryAddressWith that stops waiting when the context is done.
+func SetPrimaryAddressWithContext(ctx context.Context, t Transport, address int, newAddress int) error {
+	return mbus.SetPrimaryAddressWithContext(ctx, t, address, newAddress)
+}
+
+// SetPrimaryAddressSecondary selects the device by its secondary address and changes its primary address.
+func SetPrimaryAddressSecondary(port string, address SecondaryAddress, newAddress int) error {
+	return mbus.SetPrimaryAddressSecondary(port, address, newAddress)
+}
+
+// SetPrimaryAddressSecondaryContext is SetPrimaryAddressSecondary that stops waiting when the context is done.
+func SetPrimaryAddressSecondaryContext(ctx context.Context, port string, address SecondaryAddress, newAddress int) error {
+	return mbus.SetPrimaryAddressSecondaryContext(ctx, port, address, newAddress)
+}
+
+// SetPrimaryAddressSecondaryWith changes the primary address of the device selected by its
+// secondary address using an already open transport.
+func SetPrimaryAddressSecondaryWith(t Transport, address SecondaryAddress, newAddress int) error {
+	return mbus.SetPrimaryAddressSecondaryWith(t, address, newAddress)
+}
+
+// SetPrimaryAddressSecondaryWithContext is SetPrimaryAddressSecondaryWith that stops waiting when the context is done.
+func SetPrimaryAddressSecondaryWithContext(ctx context.Context, t Transport, address SecondaryAddress, newAddress int) error {
+	return mbus.SetPrimaryAddressSecondaryWithContext(ctx, t, address, newAddress)
+}
+
+// Commission searches all devices by secondary address and assigns primary addresses according to the plan.
+func Commission(port string, plan CommissionPlan) (Inventory, error) {
+	return mbus.Commission(port, plan)
+}
+
+// CommissionWith commissions the devices using an already open transport.
+func CommissionWith(t Transport, plan CommissionPlan) (Inventory, error) {
+	return mbus.CommissionWith(t, plan)
+}
+
+// CommissionContext is Commission that stops waiting when the context is done.
+func CommissionContext(ctx context.Context, port string, plan CommissionPlan) (Inventory, error) {
+	return mbus.CommissionContext(ctx, port, plan)
+}
+
+// CommissionWithContext is CommissionWith that stops waiting when the context is done.
+func CommissionWithContext(ctx context.Context, t Transport, plan CommissionPlan) (Inventory, error) {
+	return mbus.CommissionWithContext(ctx, t, plan)
+}
+
+// ReadCommissionPlan reads a YAML commissioning plan.
+func ReadCommissionPlan(r io.Reader) (CommissionPlan, error) {
+	return mbus.ReadCommissionPlan(r)
+}
+
+// SwitchBaudRate commands the device to change its baud rate.
+func SwitchBaudRate(port string, address int, baud int) error {
+	return mbus.SwitchBaudRate(port, address, baud)
+}
+
+// SwitchBaudRateWith commands the device to change its baud rate using an already open transport.
+func SwitchBaudRateWith(t Transport, address int, baud int) error {
+	return mbus.SwitchBaudRateWith(t, address, baud)
+}
+
+// SwitchBaudRateContext is SwitchBaudRate that stops waiting when the context is done.
+func SwitchBaudRateContext(ctx context.Context, port string, address int, baud int) error {
+	return mbus.SwitchBaudRateContext(ctx, port, address, baud)
+}
+
+// SwitchBaudRateWithContext is SwitchBaudRateWith that stops waiting when the context is done.
+func SwitchBaudRateWithContext(ctx context.Context, t Transport, address int, baud int) error {
+	return mbus.SwitchBaudRateWithContext(ctx, t, address, baud)
+}
+
+// DetectBaudRate finds the baud rate of the device by trying each standard rate.
+func DetectBaudRate(port string, address int) (int, error) {
+	return mbus.DetectBaudRate(port, address)
+}
+
+// DetectBaudRateWith finds the baud rate of the device using an already open transport.
+func DetectBaudRateWith(t Transport, address int) (int, error) {
+	return mbus.DetectBaudRateWith(t, address)
+}
+
+// DetectBaudRateContext is DetectBaudRate that stops waiting when the context is done.
+func DetectBaudRateContext(ctx context.Context, port string, address int) (int, error) {
+	return mbus.DetectBaudRateContext(ctx, port, address)
+}
+
+// DetectBaudRateWithContext is DetectBaudRateWith that stops waiting when the context is done.
+func DetectBaudRateWithContext(ctx context.Context, t Transport, address int) (int, error) {
+	return mbus.DetectBaudRateWithContext(ctx, t, address)
+}
+
+// ScanBaudRates pings the addresses at each standard baud rate and reports the rate of every device found.
+func ScanBaudRates(port string, first int, last int) ([]PingState, error) {
+	return mbus.ScanBaudRates(port, first, last)
+}
+
+// ScanBaudRatesWith pings the addresses at each standard baud rate using an already open transport.
+func ScanBaudRatesWith(t Transport, first int, last int) ([]PingState, error) {
+	return mbus.ScanBaudRatesWith(t, first, last)
+}
+
+// ScanBaudRatesContext is ScanBaudRates that stops waiting when the context is done.
+func ScanBaudRatesContext(ctx context.Context, port string, first int, last int) ([]PingState, error) {
+	return mbus.ScanBaudRatesContext(ctx, port, first, last)
+}
+
+// ScanBaudRatesWithContext is ScanBaudRatesWith that stops waiting when the context is done.
+func ScanBaudRatesWithContext(ctx context.Context, t Transport, first int, last int) ([]PingState, error) {
+	return mbus.ScanBaudRatesWithContext(ctx, t, first, last)
+}
+
+// ApplicationReset resets the application of the device, the subcode selects the data it returns next.
+func ApplicationReset(port string, address int, subcode ResetSubcode) error {
+	return mbus.ApplicationReset(port, address, subcode)
+}
+
+// ApplicationResetWith resets the application of the device using an already open transport.
+func ApplicationResetWith(t Transport, address int, subcode ResetSubcode) error {
+	return mbus.ApplicationResetWith(t, address, subcode)
+}
+
+// ApplicationResetContext is ApplicationReset that stops waiting when the context is done.
+func ApplicationResetContext(ctx context.Context, port string, address int, subcode ResetSubcode) error {
+	return mbus.ApplicationResetContext(ctx, port, address, subcode)
+}
+
+// ApplicationResetWithContext is ApplicationResetWith that stops waiting when the context is done.
+func ApplicationResetWithContext(ctx context.Context, t Transport, address int, subcode ResetSubcode) error {
+	return mbus.ApplicationResetWithContext(ctx, t, address, subcode)
+}
+
+// SetDateTime writes the date and time to the device as CP32 or CP48 time point.
+func SetDateTime(port string, address int, dateTime time.Time, format TimePointType) error {
+	return mbus.SetDateTime(port, address, dateTime, format)
+}
+
+// SetDateTimeWith writes the date and time to the device using an already open transport.
+func SetDateTimeWith(t Transport, address int, dateTime time.Time, format TimePointType) error {
+	return mbus.SetDateTimeWith(t, address, dateTime, format)
+}
+
+// SetDateTimeContext is SetDateTime that stops waiting when the context is done.
+func SetDateTimeContext(ctx context.Context, port string, address int, dateTime time.Time, format TimePointType) error {
+	return mbus.SetDateTimeContext(ctx, port, address, dateTime, format)
+}
+
+// SetDateTimeWithContext is SetDateTimeWith that stops waiting when the context is done.
+func SetDateTimeWithContext(ctx context.Context, t Transport, address int, dateTime time.Time, format TimePointType) error {
+	return mbus.SetDateTimeWithContext(ctx, t, address, dateTime, format)
+}
+
+// Synchronize broadcasts the date and time to all devices on the port.
+func Synchronize(port string, dateTime time.Time, format TimePointType) error {
+	return mbus.Synchronize(port, dateTime, format)
+}
+
+// SynchronizeWith broadcasts the date and time to all devices using an already open transport.
+func SynchronizeWith(t Transport, dateTime time.Time, format TimePointType) error {
+	return mbus.SynchronizeWith(t, dateTime, format)
+}
+
+// SynchronizeContext is Synchronize that stops waiting when the context is done.
+func SynchronizeContext(ctx context.Context, port string, dateTime time.Time, format TimePointType) error {
+	return mbus.SynchronizeContext(ctx, port, dateTime, format)
+}
+
+// SynchronizeWithContext is SynchronizeWith that stops waiting when the context is done.
+func SynchronizeWithContext(ctx context.Context, t Transport, dateTime time.Time, format TimePointType) error {
+	return mbus.SynchronizeWithContext(ctx, t, dateTime, format)
+}
+
+// ReadAlarm requests class 1 data (REQ_UD1) from the device and reports its alarm status.
+func ReadAlarm(port string, address int) AlarmState {
+	return mbus.ReadAlarm(port, address)
+}
+
+// ReadAlarmWith requests class 1 data from the device using an already open transport.
+// Pass the returned frame count bit to the next request to the device.
+func ReadAlarmWith(t Transport, address int, fcb bool) (AlarmState, bool) {
+	return mbus.ReadAlarmWith(t, address, fcb)
+}
+
+// ReadAlarmContext is ReadAlarm that stops waiting when the context is done.
+func ReadAlarmContext(ctx context.Context, port string, address int) AlarmState {
+	return mbus.ReadAlarmContext(ctx, port, address)
+}
+
+// ReadAlarmWithContext is ReadAlarmWith that stops waiting when the context is done.
+func ReadAlarmWithContext(ctx context.Context, t Transport, address int, fcb bool) (AlarmState, bool) {
+	return mbus.ReadAlarmWithContext(ctx, t, address, fcb)
+}
+
+// WriteRecords writes the data records to the device with SND_UD and waits for the ACK.
+func WriteRecords(port string, address int, records []RecordWrite) error {
+	return mbus.WriteRecords(port, address, records)
+}
+
+// WriteRecordsWith writes the data records to the device using an already open transport.
+func WriteRecordsWith(t Transport, address int, records []RecordWrite) error {
+	return mbus.WriteRecordsWith(t, address, records)
+}
+
+// WriteRecordsContext is WriteRecords that stops waiting when the context is done.
+func WriteRecordsContext(ctx context.Context, port string, address int, records []RecordWrite) error {
+	return mbus.WriteRecordsContext(ctx, port, address, records)
+}
+
+// WriteRecordsWithContext is WriteRecordsWith that stops waiting when the context is done.
+func WriteRecordsWithContext(ctx context.Context, t Transport, address int, records []RecordWrite) error {
+	return mbus.WriteRecordsWithContext(ctx, t, address, records)
+}
+
+// OpenTransport opens the transport for the given port string.
+func OpenTransport(port string) (Transport, error) {
+	return mbus.OpenTransport(port)
+}
+
+// OpenBus opens the port and starts a long-lived bus session that keeps the port open.
+func OpenBus(port string) (*Bus, error) {
+	return mbus.OpenBus(port)
+}
+
+// OpenBusContext is OpenBus that stops waiting for the port when the context is done.
+func OpenBusContext(ctx context.Context, port string) (*Bus, error) {
+	return mbus.OpenBusContext(ctx, port)
+}
+
+// NewBus starts a bus session on an open transport.
+func NewBus(t Transport) *Bus {
+	return mbus.NewBus(t)
+}
+
+// NewBusAt starts a bus session on an open transport that is set to the baud rate.
+func NewBusAt(t Transport, baud int) (*Bus, error) {
+	return mbus.NewBusAt(t, baud)
+}
+
+// Decode verifies and decodes raw telegram bytes without a serial port.
+func Decode(data []byte) (*Telegram, error) {
+	t, err := mbus.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return convertTelegram(t), nil
+}
+
+// DecodeHex decodes a telegram written as HEX string.
+func DecodeHex(s string) (*Telegram, error) {
+	t, err := mbus.DecodeHex(s)
+	if err != nil {
+		return nil, err
+	}
+	return convertTelegram(t), nil
+}
+
+// convertDeviceState converts to our DeviceState with our LFrameRecord
+func convertDeviceState(ds mbus.DeviceState) DeviceState {
+	return DeviceState{
+		Port:             ds.Port,
+		Address:          ds.Address,
+		SecondaryAddress: ds.SecondaryAddress,
+		Timestamp:        ds.Timestamp,
+		Error:            ds.Error,
+		Data:             convertParsed(ds.Data),
+	}
+}
+
+// convertTelegram converts to our Telegram with our LFrameRecord
+func convertTelegram(t *mbus.Telegram) *Telegram {
+	return &Telegram{
+		LFrameParsed: convertParsed(t.LFrameParsed),
+		CField:       t.CField,
+		CIField:      t.CIField,
+	}
+}
+
+// convertParsed converts to our LFrameParsed with our LFrameRecord
+func convertParsed(p mbus.LFrameParsed) LFrameParsed {
+	result := LFrameParsed{
+		IdentificationNumber: p.IdentificationNumber,
+		Manufacturer:         p.Manufacturer,
+		Version:              p.Version,
+		Medium:               p.Medium,
+		AccessNumber:         p.AccessNumber,
+		Status:               p.Status,
+		Model:                p.Model,
+		Address:              p.Address,
+		Signature:            p.Signature,
+		Records:              make(map[int]LFrameRecord),
+		Telegrams:            p.Telegrams,
 	}
 
 	// Convert each record, setting Description to Name
-	for i, record := range ds.Data.Records {
-		result.Data.Records[i] = LFrameRecord{
+	for i, record := range p.Records {
+		result.Records[i] = LFrameRecord{
 			DIF:         record.DIF,
 			DIFE:        record.DIFE,
 			VIF:         record.VIF,
@@ -62,13 +447,145 @@ func Read(port string, address int) DeviceState {
 // PingState represents the state of a ping operation.
 type PingState = mbus.PingState
 
+// AlarmState represents the alarm status of a device.
+type AlarmState = mbus.AlarmState
+
+// Transport is a byte link to an M-Bus segment.
+type Transport = mbus.Transport
+
+// Bus is a long-lived session on one M-Bus segment.
+type Bus = mbus.Bus
+
+// SecondaryAddress identifies a device by identification number, manufacturer, version and medium.
+type SecondaryAddress = mbus.SecondaryAddress
+
+// Response is the class of the answer of the devices to a request.
+type Response = mbus.Response
+
+// Classes of the answer to a request.
+const (
+	ResponseNone      = mbus.ResponseNone
+	ResponseAck       = mbus.ResponseAck
+	ResponseFrame     = mbus.ResponseFrame
+	ResponseCollision = mbus.ResponseCollision
+)
+
+// RetryPolicy tells the bus how to repeat a request that failed on the link layer.
+type RetryPolicy = mbus.RetryPolicy
+
+// DefaultRetryPolicy returns the policy of a new bus, it sends every request once.
+func DefaultRetryPolicy() RetryPolicy {
+	return mbus.DefaultRetryPolicy()
+}
+
+// IsRetryable returns true for errors of a missing or damaged answer.
+func IsRetryable(err error) bool {
+	return mbus.IsRetryable(err)
+}
+
+// WithResponseTimeout returns the transport with a response timeout that replaces the
+// one of the standard in the functions on an open transport, e.g. ReadWith.
+func WithResponseTimeout(t Transport, timeout time.Duration) Transport {
+	return mbus.WithResponseTimeout(t, timeout)
+}
+
+// RecordSelector selects one data record for readout.
+type RecordSelector = mbus.RecordSelector
+
+// RecordWrite is one data record written to a device.
+type RecordWrite = mbus.RecordWrite
+
+// RecordAction is the object action of a written record.
+type RecordAction = mbus.RecordAction
+
+// Object actions of a written record.
+const (
+	ActionWrite             = mbus.ActionWrite
+	ActionAdd               = mbus.ActionAdd
+	ActionSubtract          = mbus.ActionSubtract
+	ActionOr                = mbus.ActionOr
+	ActionAnd               = mbus.ActionAnd
+	ActionXor               = mbus.ActionXor
+	ActionAndNot            = mbus.ActionAndNot
+	ActionClear             = mbus.ActionClear
+	ActionAddEntry          = mbus.ActionAddEntry
+	ActionDeleteEntry       = mbus.ActionDeleteEntry
+	ActionFreeze            = mbus.ActionFreeze
+	ActionAddToReadout      = mbus.ActionAddToReadout
+	ActionDeleteFromReadout = mbus.ActionDeleteFromReadout
+)
+
+// Data field codes of a written record.
+const (
+	DataFieldNoData   = mbus.DataFieldNoData
+	DataFieldInt8     = mbus.DataFieldInt8
+	DataFieldInt16    = mbus.DataFieldInt16
+	DataFieldInt24    = mbus.DataFieldInt24
+	DataFieldInt32    = mbus.DataFieldInt32
+	DataFieldReal32   = mbus.DataFieldReal32
+	DataFieldInt48    = mbus.DataFieldInt48
+	DataFieldInt64    = mbus.DataFieldInt64
+	DataFieldBCD2     = mbus.DataFieldBCD2
+	DataFieldBCD4     = mbus.DataFieldBCD4
+	DataFieldBCD6     = mbus.DataFieldBCD6
+	DataFieldBCD8     = mbus.DataFieldBCD8
+	DataFieldVariable = mbus.DataFieldVariable
+	DataFieldBCD12    = mbus.DataFieldBCD12
+)
+
+// TimePointType selects the encoding of the date and time written to a device.
+type TimePointType = mbus.TimePointType
+
+// Encodings of the date and time.
+const (
+	TimePointCP32 = mbus.TimePointCP32
+	TimePointCP48 = mbus.TimePointCP48
+)
+
+// ResetSubcode selects the data a device returns after an application reset.
+type ResetSubcode = mbus.ResetSubcode
+
+// Subcodes of the application reset.
+const (
+	ResetAll                 = mbus.ResetAll
+	ResetUserData            = mbus.ResetUserData
+	ResetSimpleBilling       = mbus.ResetSimpleBilling
+	ResetEnhancedBilling     = mbus.ResetEnhancedBilling
+	ResetMultiTariffBilling  = mbus.ResetMultiTariffBilling
+	ResetInstantaneousValues = mbus.ResetInstantaneousValues
+	ResetLoadManagement      = mbus.ResetLoadManagement
+	ResetInstallation        = mbus.ResetInstallation
+	ResetTesting             = mbus.ResetTesting
+	ResetCalibration         = mbus.ResetCalibration
+	ResetManufacturing       = mbus.ResetManufacturing
+	ResetDevelopment         = mbus.ResetDevelopment
+	ResetSelftest            = mbus.ResetSelftest
+)
+
+// CommissionPlan tells which primary address each device gets.
+type CommissionPlan = mbus.CommissionPlan
+
+// Inventory is the result of a commissioning run.
+type Inventory = mbus.Inventory
+
+// SecondarySearch is the result of a secondary address search.
+type SecondarySearch = mbus.SecondarySearch
+
 // DeviceState represents the state of a device.
 type DeviceState struct {
-	Port      string       `json:"port"`
-	Address   int          `json:"address"`
-	Data      LFrameParsed `json:"data"`
-	Timestamp time.Time    `json:"timestamp"`
-	Error     string       `json:"error"`
+	Port             string       `json:"port"`
+	Address          int          `json:"address"`
+	SecondaryAddress string       `json:"secondary_address,omitempty"`
+	Data             LFrameParsed `json:"data"`
+	Timestamp        time.Time    `json:"timestamp"`
+	Error            string       `json:"error"`
+}
+
+// Telegram is a decoded RSP_UD long frame.
+type Telegram struct {
+	LFrameParsed
+	CField  byte `yaml:"c_field" json:"c_field"`
+	CIField byte `yaml:"ci_field" json:"ci_field"`
 }
 
 // LFrameParsed represents a parsed M-Bus telegram.
@@ -83,8 +600,12 @@ type LFrameParsed struct {
 	Address              uint8  `yaml:"address" json:"address"`
 	Signature            []byte `yaml:"signature" json:"signature"`
 	Records              map[int]LFrameRecord
+	Telegrams            []TelegramInfo `yaml:"telegrams" json:"telegrams"`
 }
 
+// TelegramInfo is the header of one RSP_UD telegram of a multi-telegram readout.
+type TelegramInfo = mbus.TelegramInfo
+
 // LFrameRecord represents a record in an M-Bus telegram.
 type LFrameRecord struct {
 	DIF         byte    `yaml:"DIF" json:"DIF"`
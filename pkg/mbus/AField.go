@@ -52,3 +52,11 @@ func (a *AField) IsBroadcastAddress() bool {
 }
 
 var AFieldBroadcastAddress = NewAField(255)
+
+// IsNetworkLayerAddress returns true if the AField is the network layer address. 253 is the address
+// of the slave selected by its secondary address.
+func (a *AField) IsNetworkLayerAddress() bool {
+	return byte(*a) == byte(AFieldNetworkLayerAddress)
+}
+
+var AFieldNetworkLayerAddress = NewAField(253)
@@ -0,0 +1,45 @@
+package mbus
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MBus Telegram Format - Single Character 0xE5 (ACK)
+
+type AckFrame struct {
+	data []byte
+}
+
+func NewAckFrame(data []byte) AckFrame {
+	return AckFrame{data}
+}
+
+// ErrNoField is returned for fields the single character frame does not have
+var ErrNoField = errors.New("single character frame has no C and A field")
+
+// Verify that the AckFrame is the single character 0xE5.
+func (f *AckFrame) Verify() (bool, error) {
+	if len(f.data) == 0 || f.data[0] != FRAME_ACK_START {
+		return false, fmt.Errorf("%w: AckFrame is not 0xE5", ErrFrameStart)
+	}
+	if len(f.data) != 1 {
+		return false, fmt.Errorf("%w: AckFrame length is %d and not 1", ErrFrameLength, len(f.data))
+	}
+	return true, nil
+}
+
+// CField does not exist in the single character frame
+func (f *AckFrame) CField() (CField, error) {
+	return CField{}, ErrNoField
+}
+
+// AField does not exist in the single character frame
+func (f *AckFrame) AField() (AField, error) {
+	return NewAField(0), ErrNoField
+}
+
+// Bytes returns the raw frame
+func (f *AckFrame) Bytes() []byte {
+	return f.data
+}
@@ -1,8 +1,12 @@
 package mbus
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // MBus Telegram Format - Control Frame
+// 0x68 0x03 0x03 0x68 C A CI Checksum 0x16
 
 type CFrame struct {
 	data []byte
@@ -12,40 +16,60 @@ func NewCFrame(data []byte) CFrame {
 	return CFrame{data}
 }
 
-// Verify that the CFrame is a control frame.
+// Verify that the CFrame is a control frame with a valid checksum.
 func (f *CFrame) Verify() (bool, error) {
 
-	// Start with 0x68
-	if f.data[0] != 0x68 {
-		return false, errors.New("CFrame does not start with 0x68")
+	// Start with 0x68 L L 0x68
+	if len(f.data) < 4 || f.data[0] != FRAME_CONTROL_START || f.data[3] != FRAME_CONTROL_START {
+		return false, fmt.Errorf("%w: CFrame does not start with 0x68", ErrFrameStart)
+	}
+	// L-field must be 3 and length must be 9
+	if f.data[1] != 3 || f.data[2] != 3 {
+		return false, fmt.Errorf("%w: CFrame L-field is 0x%02X 0x%02X and not 0x03", ErrFrameLength, f.data[1], f.data[2])
+	}
+	if len(f.data) != 9 {
+		return false, fmt.Errorf("%w: CFrame length is %d and not 9", ErrFrameLength, len(f.data))
 	}
 	// End with 0x16
-	if f.data[len(f.data)-1] != 0x16 {
-		return false, errors.New("CFrame does not end with 0x16")
+	if f.data[8] != FRAME_STOP {
+		return false, fmt.Errorf("%w: CFrame does not end with 0x16", ErrFrameStop)
 	}
-	// Length must be 6
-	if len(f.data) != 6 {
-		return false, errors.New("CFrame length is not 6")
+	// Checksum of C, A and CI field
+	if crc := checksum(f.data[4:7]); crc != f.data[7] {
+		return false, fmt.Errorf("%w: CFrame checksum is 0x%02X and not 0x%02X", ErrFrameChecksum, f.data[7], crc)
 	}
-	// Check checksum
 
 	return true, nil
 }
 
-// CField is in index 5
+// CField is in index 4
 func (f *CFrame) CField() (CField, error) {
 	// Check length
-	if len(f.data) < 6 {
+	if len(f.data) < 5 {
 		return CField{}, errors.New("data length is too short")
 	}
-	return NewCField(f.data[5]), nil
+	return NewCField(f.data[4]), nil
 }
 
-// AField is in index 6
+// AField is in index 5
 func (f *CFrame) AField() (AField, error) {
 	// Check length
-	if len(f.data) < 7 {
+	if len(f.data) < 6 {
 		return NewAField(0), errors.New("data length is too short")
 	}
-	return NewAField(f.data[6]), nil
+	return NewAField(f.data[5]), nil
+}
+
+// CIField is in index 6
+func (f *CFrame) CIField() (CIField, error) {
+	// Check length
+	if len(f.data) < 7 {
+		return NewCIField(0), errors.New("data length is too short")
+	}
+	return NewCIField(f.data[6]), nil
+}
+
+// Bytes returns the raw frame
+func (f *CFrame) Bytes() []byte {
+	return f.data
 }
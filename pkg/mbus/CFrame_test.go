@@ -0,0 +1,84 @@
+package mbus
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCFrame_Verify(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      []byte
+		wantValid bool
+		wantErr   error
+	}{
+		{
+			name:      "Valid CFrame",
+			data:      []byte{0x68, 0x03, 0x03, 0x68, 0x53, 0x01, 0xBD, 0x11, 0x16},
+			wantValid: true,
+			wantErr:   nil,
+		},
+		{
+			name:      "Invalid start byte",
+			data:      []byte{0x10, 0x03, 0x03, 0x68, 0x53, 0x01, 0xBD, 0x11, 0x16},
+			wantValid: false,
+			wantErr:   ErrFrameStart,
+		},
+		{
+			name:      "L-field is not 3",
+			data:      []byte{0x68, 0x04, 0x04, 0x68, 0x53, 0x01, 0xBD, 0x11, 0x16},
+			wantValid: false,
+			wantErr:   ErrFrameLength,
+		},
+		{
+			name:      "Invalid length",
+			data:      []byte{0x68, 0x03, 0x03, 0x68, 0x53, 0x01, 0xBD, 0x11},
+			wantValid: false,
+			wantErr:   ErrFrameLength,
+		},
+		{
+			name:      "Checksum mismatch",
+			data:      []byte{0x68, 0x03, 0x03, 0x68, 0x53, 0x01, 0xBD, 0x12, 0x16},
+			wantValid: false,
+			wantErr:   ErrFrameChecksum,
+		},
+		{
+			name:      "Missing stop byte",
+			data:      []byte{0x68, 0x03, 0x03, 0x68, 0x53, 0x01, 0xBD, 0x11, 0x17},
+			wantValid: false,
+			wantErr:   ErrFrameStop,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame := NewCFrame(tt.data)
+			got, err := frame.Verify()
+
+			if got != tt.wantValid {
+				t.Errorf("Verify() valid = %v, want %v", got, tt.wantValid)
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCFrame_Fields(t *testing.T) {
+	frame := NewCFrame([]byte{0x68, 0x03, 0x03, 0x68, 0x53, 0x01, 0xBD, 0x11, 0x16})
+
+	cf, err := frame.CField()
+	if err != nil || cf.getByte() != CFIELD_SND_UD_0.getByte() {
+		t.Errorf("CField() = 0x%02X, %v, want 0x%02X", cf.getByte(), err, CFIELD_SND_UD_0.getByte())
+	}
+	af, err := frame.AField()
+	if err != nil || af != AField(1) {
+		t.Errorf("AField() = %v, %v, want %v", af, err, AField(1))
+	}
+	ci, err := frame.CIField()
+	if err != nil || ci != CiFieldBaudrate9600 {
+		t.Errorf("CIField() = %v, %v, want %v", ci, err, CIField(CiFieldBaudrate9600))
+	}
+}
@@ -14,6 +14,8 @@ const (
 	// CiFieldApplicationReset Master can release a reset of application program in the slaves
 	CiFieldApplicationReset                    = 0x50
 	CiFieldSynchronizeAction                   = 0x54
+	CiFieldApplicationErrors                   = 0x70
+	CiFieldAlarm                               = 0x71
 	CiFieldVariable72                          = 0x72
 	CiFieldVariable76                          = 0x76
 	CiFieldBaudrate300                         = 0xB8
@@ -38,6 +40,49 @@ const (
 	CiFieldCodesUsedForHashing7                = 0x97
 )
 
+// BaudRate returns the baud rate the slave switches to on a CiFieldBaudrate* command.
+// The second value is false for any other CI field.
+func (cf CIField) BaudRate() (int, bool) {
+	switch cf {
+	case CiFieldBaudrate300:
+		return 300, true
+	case CiFieldBaudrate1200:
+		return 1200, true
+	case CiFieldBaudrate2400:
+		return 2400, true
+	case CiFieldBaudrate4800:
+		return 4800, true
+	case CiFieldBaudrate9600:
+		return 9600, true
+	case CiFieldBaudrate19200:
+		return 19200, true
+	case CiFieldBaudrate38400:
+		return 38400, true
+	}
+	return 0, false
+}
+
+// BaudRateCIField returns the CI field of the control frame that switches a slave to the baud rate
+func BaudRateCIField(baud int) (CIField, bool) {
+	switch baud {
+	case 300:
+		return CiFieldBaudrate300, true
+	case 1200:
+		return CiFieldBaudrate1200, true
+	case 2400:
+		return CiFieldBaudrate2400, true
+	case 4800:
+		return CiFieldBaudrate4800, true
+	case 9600:
+		return CiFieldBaudrate9600, true
+	case 19200:
+		return CiFieldBaudrate19200, true
+	case 38400:
+		return CiFieldBaudrate38400, true
+	}
+	return 0, false
+}
+
 func (cf CIField) String() string {
 	switch cf {
 	case CiFieldDataSend:
@@ -48,6 +93,10 @@ func (cf CIField) String() string {
 		return "APPLICATION_RESET"
 	case CiFieldSynchronizeAction:
 		return "SYNCHRONIZE_ACTION"
+	case CiFieldApplicationErrors:
+		return "APPLICATION_ERRORS"
+	case CiFieldAlarm:
+		return "ALARM_STATUS"
 	case CiFieldVariable72:
 		return "VARIABLE_DATA_STRUCTURE_72"
 	case CiFieldVariable76:
@@ -183,6 +183,16 @@ func TestCIField_String(t *testing.T) {
 			cifield:  CiFieldSynchronizeAction,
 			expected: "SYNCHRONIZE_ACTION",
 		},
+		{
+			name:     "Application Errors",
+			cifield:  CiFieldApplicationErrors,
+			expected: "APPLICATION_ERRORS",
+		},
+		{
+			name:     "Alarm Status",
+			cifield:  CiFieldAlarm,
+			expected: "ALARM_STATUS",
+		},
 		{
 			name:     "Variable Data Structure 72",
 			cifield:  CiFieldVariable72,
@@ -309,3 +319,43 @@ func TestCIField_String(t *testing.T) {
 		})
 	}
 }
+
+func TestCIField_BaudRate(t *testing.T) {
+	tests := []struct {
+		name     string
+		cifield  CIField
+		wantRate int
+		wantOk   bool
+	}{
+		{name: "Baudrate 300", cifield: CiFieldBaudrate300, wantRate: 300, wantOk: true},
+		{name: "Baudrate 2400", cifield: CiFieldBaudrate2400, wantRate: 2400, wantOk: true},
+		{name: "Baudrate 9600", cifield: CiFieldBaudrate9600, wantRate: 9600, wantOk: true},
+		{name: "Baudrate 38400", cifield: CiFieldBaudrate38400, wantRate: 38400, wantOk: true},
+		{name: "Data Send", cifield: CiFieldDataSend, wantRate: 0, wantOk: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, ok := tt.cifield.BaudRate()
+			if rate != tt.wantRate || ok != tt.wantOk {
+				t.Errorf("BaudRate() = %v, %v, want %v, %v", rate, ok, tt.wantRate, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestBaudRateCIField(t *testing.T) {
+	for _, baud := range []int{300, 1200, 2400, 4800, 9600, 19200, 38400} {
+		ci, ok := BaudRateCIField(baud)
+		if !ok {
+			t.Errorf("BaudRateCIField(%d) not ok", baud)
+			continue
+		}
+		if got, _ := ci.BaudRate(); got != baud {
+			t.Errorf("BaudRateCIField(%d).BaudRate() = %d", baud, got)
+		}
+	}
+	if _, ok := BaudRateCIField(600); ok {
+		t.Errorf("BaudRateCIField(600) ok, want not supported")
+	}
+}
@@ -0,0 +1,67 @@
+package mbus
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Frame is one of the M-Bus link layer frames: AckFrame, SFrame, CFrame or LFrame.
+type Frame interface {
+	// CField returns the control field. AckFrame has none.
+	CField() (CField, error)
+	// AField returns the address field. AckFrame has none.
+	AField() (AField, error)
+	// Verify checks start, length, checksum and stop byte of the frame.
+	Verify() (bool, error)
+	// Bytes returns the raw frame.
+	Bytes() []byte
+}
+
+// Link layer errors returned by the Verify methods of the frames
+var (
+	ErrFrameStart    = errors.New("bad frame start")
+	ErrFrameLength   = errors.New("frame length mismatch")
+	ErrFrameChecksum = errors.New("frame checksum mismatch")
+	ErrFrameStop     = errors.New("missing frame stop byte")
+)
+
+// ParseFrame detects the frame type from the start byte and the L-field and
+// returns the verified frame: *AckFrame for 0xE5, *SFrame for 0x10, *CFrame for
+// 0x68 with L-field 3 and *LFrame for other 0x68 frames.
+func ParseFrame(data []byte) (Frame, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: no data", ErrFrameLength)
+	}
+
+	var frame Frame
+	switch {
+	case data[0] == FRAME_ACK_START:
+		f := NewAckFrame(data)
+		frame = &f
+	case data[0] == FRAME_SHORT_START:
+		f := NewSFrame(data)
+		frame = &f
+	case data[0] == FRAME_CONTROL_START && len(data) > 1 && data[1] == 3:
+		f := NewCFrame(data)
+		frame = &f
+	case data[0] == FRAME_LONG_START:
+		f := NewLFrame(data)
+		frame = &f
+	default:
+		return nil, fmt.Errorf("%w: unknown start byte 0x%02X", ErrFrameStart, data[0])
+	}
+
+	if _, err := frame.Verify(); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+// checksum Arithmetic sum of the bytes without carry
+func checksum(data []byte) byte {
+	var crc byte
+	for _, b := range data {
+		crc += b
+	}
+	return crc
+}
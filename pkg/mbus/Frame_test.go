@@ -0,0 +1,77 @@
+package mbus
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestParseFrame(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		wantType string
+		wantErr  error
+	}{
+		{name: "ACK", data: []byte{0xE5}, wantType: "ack"},
+		{name: "Short frame", data: []byte{0x10, 0x40, 0x01, 0x41, 0x16}, wantType: "short"},
+		{name: "Control frame", data: []byte{0x68, 0x03, 0x03, 0x68, 0x53, 0x01, 0xBD, 0x11, 0x16}, wantType: "control"},
+		{name: "Long frame", data: testRspUd, wantType: "long"},
+		{name: "Empty", data: []byte{}, wantErr: ErrFrameLength},
+		{name: "ACK with trailing bytes", data: []byte{0xE5, 0xE5}, wantErr: ErrFrameLength},
+		{name: "Unknown start byte", data: []byte{0x42}, wantErr: ErrFrameStart},
+		{name: "Bad checksum", data: []byte{0x10, 0x40, 0x01, 0x42, 0x16}, wantErr: ErrFrameChecksum},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame, err := ParseFrame(tt.data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseFrame() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			var gotType string
+			switch frame.(type) {
+			case *AckFrame:
+				gotType = "ack"
+			case *SFrame:
+				gotType = "short"
+			case *CFrame:
+				gotType = "control"
+			case *LFrame:
+				gotType = "long"
+			}
+			if gotType != tt.wantType {
+				t.Errorf("ParseFrame() type = %T, want %s", frame, tt.wantType)
+			}
+			if !bytes.Equal(frame.Bytes(), tt.data) {
+				t.Errorf("ParseFrame() Bytes() = % X, want % X", frame.Bytes(), tt.data)
+			}
+		})
+	}
+}
+
+func TestParseFrame_Fields(t *testing.T) {
+	frame, err := ParseFrame(testRspUd)
+	if err != nil {
+		t.Fatalf("ParseFrame() error = %v", err)
+	}
+	a, err := frame.AField()
+	if err != nil {
+		t.Fatalf("AField() error = %v", err)
+	}
+	if a != 1 {
+		t.Errorf("AField() = %v, want 1", a)
+	}
+
+	ack, err := ParseFrame([]byte{0xE5})
+	if err != nil {
+		t.Fatalf("ParseFrame() error = %v", err)
+	}
+	if _, err := ack.CField(); !errors.Is(err, ErrNoField) {
+		t.Errorf("AckFrame CField() error = %v, want %v", err, ErrNoField)
+	}
+}
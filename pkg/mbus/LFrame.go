@@ -40,27 +40,48 @@ type LFrameParsed struct {
 	Address              uint8  `yaml:"address" json:"address"`
 	Signature            []byte `yaml:"signature" json:"signature"`
 	Records              map[int]LFrameRecord
+	// Telegrams holds the header of every RSP_UD telegram the records come from
+	Telegrams []TelegramInfo `yaml:"telegrams" json:"telegrams"`
+}
+
+// TelegramInfo is the header of one RSP_UD telegram of a readout
+type TelegramInfo struct {
+	AccessNumber      uint `yaml:"access_number" json:"access_number"`
+	Status            byte `yaml:"status" json:"status"`
+	FirstRecord       int  `yaml:"first_record" json:"first_record"`
+	RecordCount       int  `yaml:"record_count" json:"record_count"`
+	MoreRecordsFollow bool `yaml:"more_records_follow" json:"more_records_follow"`
 }
 
 func NewLFrame(data []byte) LFrame {
 	return LFrame{data: data}
 }
 
-// Verify Frame
+// Verify Frame: start bytes, L-field, checksum and stop byte
 func (lf *LFrame) Verify() (bool, error) {
+	if len(lf.data) == 0 || lf.data[0] != FRAME_LONG_START {
+		return false, fmt.Errorf("%w: data does not start with 0x68", ErrFrameStart)
+	}
 	if len(lf.data) < 6 {
-		return false, fmt.Errorf("data length is too short")
+		return false, fmt.Errorf("%w: data length is too short", ErrFrameLength)
 	}
-	if lf.data[0] != 0x68 {
-		return false, fmt.Errorf("data does not start with 0x68")
+	if lf.data[3] != FRAME_LONG_START {
+		return false, fmt.Errorf("%w: data does not start with 0x68 L L 0x68", ErrFrameStart)
 	}
 	if lf.data[1] != lf.data[2] {
-		return false, fmt.Errorf("data length does not match")
+		return false, fmt.Errorf("%w: L-fields 0x%02X and 0x%02X do not match", ErrFrameLength, lf.data[1], lf.data[2])
+	}
+	if len(lf.data) != int(lf.DataHeaderLField())+6 {
+		return false, fmt.Errorf("%w: L-field is %d but frame has %d bytes", ErrFrameLength, lf.DataHeaderLField(), len(lf.data))
 	}
-	if lf.data[3] != 0x68 {
-		return false, fmt.Errorf("data does not start with 0x68")
+	if lf.data[lf.StopByteIndex()] != FRAME_STOP {
+		return false, fmt.Errorf("%w: data does not end with 0x16", ErrFrameStop)
+	}
+	// Checksum of C, A, CI field and user data
+	crcIndex := lf.StopByteIndex() - 1
+	if crc := checksum(lf.data[4:crcIndex]); crc != lf.data[crcIndex] {
+		return false, fmt.Errorf("%w: checksum is 0x%02X and not 0x%02X", ErrFrameChecksum, lf.data[crcIndex], crc)
 	}
-	// Checksum
 
 	return true, nil
 }
@@ -73,7 +94,7 @@ func (lf *LFrame) DataHeaderLField() byte {
 // CField is in index 4
 func (lf *LFrame) CField() (CField, error) {
 	// Check length
-	if len(lf.data) < 4 {
+	if len(lf.data) < 5 {
 		return CField{}, errors.New("data length is too short")
 	}
 	return NewCField(lf.data[4]), nil
@@ -109,7 +130,7 @@ func (lf *LFrame) IsFromMaster() bool {
 // AField at index 5
 func (lf *LFrame) AField() (AField, error) {
 	// Check length
-	if len(lf.data) < 5 {
+	if len(lf.data) < 6 {
 		return NewAField(0), errors.New("data length is too short")
 	}
 	return NewAField(lf.data[5]), nil
@@ -183,6 +204,24 @@ func (lf *LFrame) Signature() []byte {
 	return lf.data[17:19]
 }
 
+// variableDataLength returns how many bytes of a variable length value are read,
+// counted from the LVAR byte
+func variableDataLength(lvar byte) int {
+	switch {
+	case lvar <= 0xBF:
+		return 1 + int(lvar)
+	case lvar <= 0xCF:
+		return int(lvar - 0xC0)
+	case lvar <= 0xDF:
+		return int(lvar - 0xD0)
+	case lvar <= 0xEF:
+		return int(lvar - 0xE0)
+	case lvar <= 0xFA:
+		return int(lvar - 0xF0)
+	}
+	return 1
+}
+
 // VariableDataRecord First Record start in index 20
 func (lf *LFrame) VariableDataRecord(firstPosition1 int) (LFrameRecord, int, error) {
 
@@ -197,10 +236,16 @@ func (lf *LFrame) VariableDataRecord(firstPosition1 int) (LFrameRecord, int, err
 
 	index := firstPosition1 - 1
 
+	// The record has to end before the checksum, a truncated record is a length error
+	end := lf.LastDataPosition()
+	truncated := func() (LFrameRecord, int, error) {
+		return record, index, fmt.Errorf("%w: record at position %d runs past the user data", ErrFrameLength, firstPosition1)
+	}
+
 	// 1. Get DIF
 	// Check if exist index
-	if len(lf.data) < index {
-		return record, index, errors.New(fmt.Sprintf("Index out of range. data length: %v, index: %v", len(lf.data), index))
+	if index >= end {
+		return truncated()
 	}
 
 	var dif = NewDIFField(lf.data[index])
@@ -211,32 +256,42 @@ func (lf *LFrame) VariableDataRecord(firstPosition1 int) (LFrameRecord, int, err
 		parseDife := true
 		for ok := true; ok; ok = parseDife {
 			index += 1
+			if index >= end {
+				return truncated()
+			}
 			dife := NewDIFEField(lf.data[index])
 
 			parseDife = dife.hasExtension()
-			record.DIFE = append(record.DIFE)
+			record.DIFE = append(record.DIFE, byte(dife))
 		}
 
 	}
 
-	// VIF
-	index += 1
-	var vif VIFField = NewVIFField(lf.data[index])
-	record.VIF = byte(vif)
-	// MANUFACTURER SPECIFIC VIF
-	// END OF USER DATA
+	// MANUFACTURER SPECIFIC DATA
+	// END OF USER DATA, 0x1F more records follow in the next telegram
 	if record.DIF == 0x0f || record.DIF == 0x1f {
-		// SPECIAL FUNCTION - Manufacturer specific data structures to end of user data
-		endOfData := lf.LastDataPosition()
+		// SPECIAL FUNCTION - Manufacturer specific data structures to end of user data, no VIF
+		endOfData := lf.LastDataPosition() + 1
 		// Skip the value bytes as they're not used
 		return record, endOfData, nil
 	}
 
+	// VIF
+	index += 1
+	if index >= end {
+		return truncated()
+	}
+	var vif VIFField = NewVIFField(lf.data[index])
+	record.VIF = byte(vif)
+
 	// SWITCH VIF
 	switch vif {
 	case 0xFD:
 		// VIF is in next byte
 		index += 1
+		if index >= end {
+			return truncated()
+		}
 		vif = NewVIFField(lf.data[index])
 		record.VIF = byte(vif)
 		record.Unit = vif.unit()
@@ -245,6 +300,9 @@ func (lf *LFrame) VariableDataRecord(firstPosition1 int) (LFrameRecord, int, err
 		0xFB:
 		// VIF is in next byte
 		index += 1
+		if index >= end {
+			return truncated()
+		}
 		vif = NewVIFField(lf.data[index])
 		record.VIF = byte(vif)
 
@@ -252,6 +310,9 @@ func (lf *LFrame) VariableDataRecord(firstPosition1 int) (LFrameRecord, int, err
 			parseVife := true
 			for ok := true; ok; ok = parseVife {
 				index += 1
+				if index >= end {
+					return truncated()
+				}
 				vife := VIFEField{lf.data[index], 0xFB}
 				// MANUFACTURER VIFE
 				// If has Extension, then find  next VIFE
@@ -260,6 +321,9 @@ func (lf *LFrame) VariableDataRecord(firstPosition1 int) (LFrameRecord, int, err
 				if vife.b == 0xFF {
 					// Manufacturer specific. Next byte is Manufacturer specific VIFE
 					index += 1
+					if index >= end {
+						return truncated()
+					}
 					manufacturerVIFE := lf.data[index]
 					// MANUFACTURER VIFE
 					record.VIFEM = append(record.VIFEM, manufacturerVIFE)
@@ -274,6 +338,9 @@ func (lf *LFrame) VariableDataRecord(firstPosition1 int) (LFrameRecord, int, err
 	case 0x7f, 0xff:
 		// Manufacturer specific. Next byte is Manufacturer specific VIFE
 		index += 1
+		if index >= end {
+			return truncated()
+		}
 		manufacturerVIFE := lf.data[index]
 		record.VIFEM = append(record.VIFEM, manufacturerVIFE)
 
@@ -287,6 +354,9 @@ func (lf *LFrame) VariableDataRecord(firstPosition1 int) (LFrameRecord, int, err
 			parseVife := true
 			for ok := true; ok; ok = parseVife {
 				index += 1
+				if index >= end {
+					return truncated()
+				}
 				vife := NewVIFEField(lf.data[index], byte(vif))
 
 				// If it has Extension, then find  next VIFE
@@ -295,6 +365,9 @@ func (lf *LFrame) VariableDataRecord(firstPosition1 int) (LFrameRecord, int, err
 				if vife.b == 0xFF {
 					// Manufacturer specific. Next byte is Manufacturer specific VIFE
 					index += 1
+					if index >= end {
+						return truncated()
+					}
 					manufacturerVIFE := lf.data[index]
 					record.VIFEM = append(record.VIFEM, manufacturerVIFE)
 					parseVife = HasBit(manufacturerVIFE, 8)
@@ -316,12 +389,20 @@ func (lf *LFrame) VariableDataRecord(firstPosition1 int) (LFrameRecord, int, err
 	// True VIF is next byte after VIF 0xFB, 0XFD
 	if vif == 0xFD || vif == 0xFB {
 		index += 1
+		if index >= end {
+			return truncated()
+		}
 		trueVIF := NewVIFField(lf.data[index])
 		exponent = trueVIF.exponent()
 	} else {
 		exponent = vif.exponent()
 	}
 
+	// Fixed length data, the variable length is checked with its LVAR
+	if dif.dataLengthName() != "VARIABLE_LENGTH" && index+dif.dataLength() > end {
+		return truncated()
+	}
+
 	switch dif.dataLengthName() {
 	case "BIT_8_INTEGER":
 		valueLengthBytes := dif.dataLength()
@@ -374,7 +455,13 @@ func (lf *LFrame) VariableDataRecord(firstPosition1 int) (LFrameRecord, int, err
 		valueLengthBytes := dif.dataLength()
 		dataOfRecord := lf.data[index : index+valueLengthBytes]
 		discard(BytesToHexString(dataOfRecord))
-		value = From48int(dataOfRecord, exponent)
+
+		if vif == 0x6d {
+			/// TIME POINT (date/time with seconds)
+			value, _ = From48intTimePoint(dataOfRecord)
+		} else {
+			value = From48int(dataOfRecord, exponent)
+		}
 		// output["value"] = value
 		index += dif.dataLength()
 	case "BIT_64_INTEGER":
@@ -395,7 +482,13 @@ func (lf *LFrame) VariableDataRecord(firstPosition1 int) (LFrameRecord, int, err
 		value = FromBCD(dataOfRecord, exponent)
 		index += dif.dataLength()
 	case "VARIABLE_LENGTH":
+		if index >= end {
+			return truncated()
+		}
 		lvar := lf.data[index]
+		if length := variableDataLength(lvar); index+length > end {
+			return truncated()
+		}
 		//fmt.Printf("LVAR: 0x%02x\n", lvar)
 		//fmt.Println(bytesToHexString(lf.data[index : index+int(lvar)]))
 		switch true {
@@ -472,12 +565,16 @@ func (lf *LFrame) Records() (map[int]LFrameRecord, error) {
 	var err error
 
 	// Go through all data
-	for ok := true; ok; ok = position < lf.LastDataPosition() {
+	for position <= lf.LastDataPosition() {
+		// SPECIAL FUNCTION - Idle filler between records
+		if lf.data[position-1] == 0x2f {
+			position += 1
+			continue
+		}
 		record := LFrameRecord{}
 		record, position, err = lf.VariableDataRecord(position)
 		if err != nil {
-			err := errors.New(fmt.Sprintf("Error parse data start at:%v  error:%s", position, err))
-			return records, err
+			return records, fmt.Errorf("Error parse data start at:%v  error:%w", position, err)
 		}
 		records[recordNumber] = record
 		recordNumber += 1
@@ -509,9 +606,59 @@ func (lf *LFrame) parse() (LFrameParsed, error) {
 	}
 	normalized.Records = records
 
+	status, _ := lf.Status()
+	normalized.Telegrams = []TelegramInfo{{
+		AccessNumber:      normalized.AccessNumber,
+		Status:            status,
+		FirstRecord:       0,
+		RecordCount:       len(records),
+		MoreRecordsFollow: moreRecordsFollow(records),
+	}}
+
 	return normalized, nil
 }
 
+// moreRecordsFollow returns true if the records end with DIF 0x1F: the slave
+// has more data and the master should send the next REQ_UD2
+func moreRecordsFollow(records map[int]LFrameRecord) bool {
+	for _, record := range records {
+		if record.DIF == 0x1F {
+			return true
+		}
+	}
+	return false
+}
+
+// dropMoreRecordsFollow removes the DIF 0x1F records, they only mark that the
+// readout continues. The flag stays in Telegrams.
+func (p *LFrameParsed) dropMoreRecordsFollow() {
+	records := make(map[int]LFrameRecord, len(p.Records))
+	for i := 0; i < len(p.Records); i++ {
+		if p.Records[i].DIF == 0x1F {
+			continue
+		}
+		records[len(records)] = p.Records[i]
+	}
+	p.Records = records
+	for i := range p.Telegrams {
+		p.Telegrams[i].RecordCount = len(records)
+	}
+}
+
+// append adds the records and the telegram header of the next telegram of
+// a multi-telegram readout
+func (p *LFrameParsed) append(next LFrameParsed) {
+	first := len(p.Records)
+	for i := 0; i < len(next.Records); i++ {
+		p.Records[first+i] = next.Records[i]
+	}
+	for _, info := range next.Telegrams {
+		info.FirstRecord += first
+		p.Telegrams = append(p.Telegrams, info)
+	}
+	p.AccessNumber = next.AccessNumber
+}
+
 // normalizedPayload return normalized records in json format
 func (lf *LFrame) json(pretty bool) (string, error) {
 	parsed, err := lf.parse()
@@ -662,3 +809,8 @@ func (lf *LFrame) description() string {
 	var output string
 	return output
 }
+
+// Bytes returns the raw frame
+func (lf *LFrame) Bytes() []byte {
+	return lf.data
+}
@@ -1,6 +1,7 @@
 package mbus
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -20,41 +21,71 @@ func TestNewLFrame(t *testing.T) {
 }
 
 func TestLFrame_Verify(t *testing.T) {
+	badChecksum := append([]byte{}, testRspUd...)
+	badChecksum[len(badChecksum)-2]++
+	badStop := append([]byte{}, testRspUd...)
+	badStop[len(badStop)-1] = 0x17
+	truncated := testRspUd[:len(testRspUd)-3]
+
 	tests := []struct {
-		name       string
-		data       []byte
-		wantValid  bool
-		wantErrMsg string
+		name      string
+		data      []byte
+		wantValid bool
+		wantErr   error
 	}{
 		{
-			name:       "Valid LFrame",
-			data:       []byte{0x68, 0x0A, 0x0A, 0x68, 0x08, 0x01, 0x72, 0x78, 0x56, 0x34, 0x12, 0x24, 0x40, 0x01, 0x07, 0x55, 0x00, 0x00, 0x00, 0x16},
-			wantValid:  true,
-			wantErrMsg: "",
+			name:      "Valid LFrame",
+			data:      testRspUd,
+			wantValid: true,
+			wantErr:   nil,
 		},
 		{
-			name:       "Invalid start byte",
-			data:       []byte{0x69, 0x0A, 0x0A, 0x68, 0x08, 0x01, 0x72, 0x78, 0x56, 0x34, 0x12, 0x24, 0x40, 0x01, 0x07, 0x55, 0x00, 0x00, 0x00, 0x16},
-			wantValid:  false,
-			wantErrMsg: "data does not start with 0x68",
+			name:      "Invalid start byte",
+			data:      []byte{0x69, 0x0A, 0x0A, 0x68, 0x08, 0x01, 0x72, 0x78, 0x56, 0x34, 0x12, 0x24, 0x40, 0x01, 0x07, 0x55, 0x00, 0x00, 0x00, 0x16},
+			wantValid: false,
+			wantErr:   ErrFrameStart,
 		},
 		{
-			name:       "Invalid second start byte",
-			data:       []byte{0x68, 0x0A, 0x0A, 0x69, 0x08, 0x01, 0x72, 0x78, 0x56, 0x34, 0x12, 0x24, 0x40, 0x01, 0x07, 0x55, 0x00, 0x00, 0x00, 0x16},
-			wantValid:  false,
-			wantErrMsg: "data does not start with 0x68",
+			name:      "Invalid second start byte",
+			data:      []byte{0x68, 0x0A, 0x0A, 0x69, 0x08, 0x01, 0x72, 0x78, 0x56, 0x34, 0x12, 0x24, 0x40, 0x01, 0x07, 0x55, 0x00, 0x00, 0x00, 0x16},
+			wantValid: false,
+			wantErr:   ErrFrameStart,
 		},
 		{
-			name:       "Length mismatch",
-			data:       []byte{0x68, 0x0A, 0x0B, 0x68, 0x08, 0x01, 0x72, 0x78, 0x56, 0x34, 0x12, 0x24, 0x40, 0x01, 0x07, 0x55, 0x00, 0x00, 0x00, 0x16},
-			wantValid:  false,
-			wantErrMsg: "data length does not match",
+			name:      "Length mismatch",
+			data:      []byte{0x68, 0x0A, 0x0B, 0x68, 0x08, 0x01, 0x72, 0x78, 0x56, 0x34, 0x12, 0x24, 0x40, 0x01, 0x07, 0x55, 0x00, 0x00, 0x00, 0x16},
+			wantValid: false,
+			wantErr:   ErrFrameLength,
 		},
 		{
-			name:       "Data too short",
-			data:       []byte{0x68, 0x0A, 0x0A, 0x68, 0x08},
-			wantValid:  false,
-			wantErrMsg: "data length is too short",
+			name:      "L-field does not match frame length",
+			data:      []byte{0x68, 0x0A, 0x0A, 0x68, 0x08, 0x01, 0x72, 0x78, 0x56, 0x34, 0x12, 0x24, 0x40, 0x01, 0x07, 0x55, 0x00, 0x00, 0x00, 0x16},
+			wantValid: false,
+			wantErr:   ErrFrameLength,
+		},
+		{
+			name:      "Truncated frame",
+			data:      truncated,
+			wantValid: false,
+			wantErr:   ErrFrameLength,
+		},
+		{
+			name:      "Checksum mismatch",
+			data:      badChecksum,
+			wantValid: false,
+			wantErr:   ErrFrameChecksum,
+		},
+		{
+			name:      "Missing stop byte",
+			data:      badStop,
+			wantValid: false,
+			wantErr:   ErrFrameStop,
+		},
+		{
+			name:      "Data too short",
+			data:      []byte{0x68, 0x0A, 0x0A, 0x68, 0x08},
+			wantValid: false,
+			wantErr:   ErrFrameLength,
 		},
 	}
 
@@ -67,12 +98,8 @@ func TestLFrame_Verify(t *testing.T) {
 				t.Errorf("Verify() valid = %v, want %v", got, tt.wantValid)
 			}
 
-			if (err != nil) != (tt.wantErrMsg != "") {
-				t.Errorf("Verify() error = %v, wantErr %v", err, tt.wantErrMsg != "")
-			}
-
-			if err != nil && err.Error() != tt.wantErrMsg {
-				t.Errorf("Verify() error = %v, wantErrMsg %v", err.Error(), tt.wantErrMsg)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
 			}
 		})
 	}
@@ -344,6 +371,24 @@ func TestLFrame_Signature(t *testing.T) {
 	}
 }
 
+func TestLFrame_VariableDataRecord_DIFE(t *testing.T) {
+	// Energy of storage number 2 and tariff 1: DIF 0x84 with DIFE 0x11, VIF 0x06
+	data := []byte{0x68, 0x16, 0x16, 0x68, 0x08, 0x01, 0x72, 0x78, 0x56, 0x34, 0x12, 0x24, 0x40, 0x01, 0x07, 0x55, 0x00, 0x00, 0x00,
+		0x84, 0x11, 0x06, 0x01, 0x02, 0x03, 0x04, 0x00, 0x16}
+	frame := NewLFrame(data)
+
+	record, _, err := frame.VariableDataRecord(20)
+	if err != nil {
+		t.Fatalf("VariableDataRecord() error = %v", err)
+	}
+	if len(record.DIFE) != 1 || record.DIFE[0] != 0x11 {
+		t.Errorf("VariableDataRecord() DIFE = % X, want 11", record.DIFE)
+	}
+	if record.VIF != 0x06 {
+		t.Errorf("VariableDataRecord() VIF = 0x%02X, want 0x06", record.VIF)
+	}
+}
+
 func TestLFrame_StopByteIndex(t *testing.T) {
 	data := []byte{0x68, 0x0A, 0x0A, 0x68, 0x08, 0x01, 0x72, 0x78, 0x56, 0x34, 0x12, 0x24, 0x40, 0x01, 0x07, 0x55, 0x00, 0x00, 0x00, 0x16}
 	frame := NewLFrame(data)
@@ -365,3 +410,54 @@ func TestLFrame_LastDataPosition(t *testing.T) {
 		t.Errorf("LastDataPosition() = %v, want %v", got, want)
 	}
 }
+
+func TestLFrame_Records(t *testing.T) {
+	header := []byte{0x78, 0x56, 0x34, 0x12, 0x24, 0x40, 0x01, 0x07, 0x55, 0x00, 0x00, 0x00}
+	volume := []byte{0x0C, 0x13, 0x27, 0x04, 0x85, 0x02}
+	telegram := func(records ...[]byte) []byte {
+		data := append([]byte{}, header...)
+		for _, record := range records {
+			data = append(data, record...)
+		}
+		frame, err := EncodeLFrame(CFIELD_RSP_UD_a, AField(0x01), CiFieldVariable72, data)
+		if err != nil {
+			t.Fatalf("EncodeLFrame() error = %v", err)
+		}
+		return frame.data
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantDIF []byte
+	}{
+		{name: "Water meter telegram", data: HexStringToBytes(testTelegramHex), wantDIF: []byte{0x03, 0xDA, 0x8B}},
+		{name: "One record", data: testRspUd, wantDIF: []byte{0x0C}},
+		{name: "Idle filler", data: telegram([]byte{0x2F}, volume, []byte{0x2F, 0x2F}), wantDIF: []byte{0x0C}},
+		{name: "Manufacturer specific data", data: telegram(volume, []byte{0x0F, 0x01, 0x02, 0x03}), wantDIF: []byte{0x0C, 0x0F}},
+		{name: "More records follow", data: telegram(volume, []byte{0x1F}), wantDIF: []byte{0x0C, 0x1F}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame := NewLFrame(tt.data)
+			records, err := frame.Records()
+			if err != nil {
+				t.Fatalf("Records() error = %v", err)
+			}
+			if len(records) != len(tt.wantDIF) {
+				t.Fatalf("Records() got %d records, want %d", len(records), len(tt.wantDIF))
+			}
+			for i, dif := range tt.wantDIF {
+				if records[i].DIF != dif {
+					t.Errorf("Records()[%d].DIF = 0x%02X, want 0x%02X", i, records[i].DIF, dif)
+				}
+			}
+			// Special functions have no VIF
+			last := records[len(records)-1]
+			if (last.DIF == 0x0F || last.DIF == 0x1F) && last.VIF != 0 {
+				t.Errorf("Records()[%d].VIF = 0x%02X, want none", len(records)-1, last.VIF)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package mbus
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // MBus Telegram Format - Short Frame
 
@@ -12,22 +15,25 @@ func NewSFrame(data []byte) SFrame {
 	return SFrame{data}
 }
 
-// Verify that the SFrame is a short frame.
+// Verify that the SFrame is a short frame with a valid checksum.
 func (f *SFrame) Verify() (bool, error) {
 
 	// Start with 0x10
-	if f.data[0] != 0x10 {
-		return false, errors.New("SFrame does not start with 0x10")
-	}
-	// End with 0x16
-	if f.data[len(f.data)-1] != 0x16 {
-		return false, errors.New("SFrame does not end with 0x16")
+	if len(f.data) == 0 || f.data[0] != FRAME_SHORT_START {
+		return false, fmt.Errorf("%w: SFrame does not start with 0x10", ErrFrameStart)
 	}
 	// Length must be 5
 	if len(f.data) != 5 {
-		return false, errors.New("SFrame length is not 5")
+		return false, fmt.Errorf("%w: SFrame length is %d and not 5", ErrFrameLength, len(f.data))
+	}
+	// End with 0x16
+	if f.data[4] != FRAME_STOP {
+		return false, fmt.Errorf("%w: SFrame does not end with 0x16", ErrFrameStop)
+	}
+	// Checksum of C and A field
+	if crc := checksum(f.data[1:3]); crc != f.data[3] {
+		return false, fmt.Errorf("%w: SFrame checksum is 0x%02X and not 0x%02X", ErrFrameChecksum, f.data[3], crc)
 	}
-	// Check checksum
 
 	return true, nil
 }
@@ -49,3 +55,8 @@ func (f *SFrame) AField() (AField, error) {
 	}
 	return NewAField(f.data[2]), nil
 }
+
+// Bytes returns the raw frame
+func (f *SFrame) Bytes() []byte {
+	return f.data
+}
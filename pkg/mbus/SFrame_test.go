@@ -1,6 +1,7 @@
 package mbus
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -21,40 +22,52 @@ func TestNewSFrame(t *testing.T) {
 
 func TestSFrame_Verify(t *testing.T) {
 	tests := []struct {
-		name       string
-		data       []byte
-		wantValid  bool
-		wantErrMsg string
+		name      string
+		data      []byte
+		wantValid bool
+		wantErr   error
 	}{
 		{
-			name:       "Valid SFrame",
-			data:       []byte{0x10, 0x40, 0x01, 0x41, 0x16},
-			wantValid:  true,
-			wantErrMsg: "",
+			name:      "Valid SFrame",
+			data:      []byte{0x10, 0x40, 0x01, 0x41, 0x16},
+			wantValid: true,
+			wantErr:   nil,
+		},
+		{
+			name:      "Invalid start byte",
+			data:      []byte{0x11, 0x40, 0x01, 0x41, 0x16},
+			wantValid: false,
+			wantErr:   ErrFrameStart,
+		},
+		{
+			name:      "Invalid end byte",
+			data:      []byte{0x10, 0x40, 0x01, 0x41, 0x17},
+			wantValid: false,
+			wantErr:   ErrFrameStop,
 		},
 		{
-			name:       "Invalid start byte",
-			data:       []byte{0x11, 0x40, 0x01, 0x41, 0x16},
-			wantValid:  false,
-			wantErrMsg: "SFrame does not start with 0x10",
+			name:      "Invalid checksum",
+			data:      []byte{0x10, 0x40, 0x01, 0x42, 0x16},
+			wantValid: false,
+			wantErr:   ErrFrameChecksum,
 		},
 		{
-			name:       "Invalid end byte",
-			data:       []byte{0x10, 0x40, 0x01, 0x41, 0x17},
-			wantValid:  false,
-			wantErrMsg: "SFrame does not end with 0x16",
+			name:      "Invalid length (too short)",
+			data:      []byte{0x10, 0x40, 0x01, 0x16},
+			wantValid: false,
+			wantErr:   ErrFrameLength,
 		},
 		{
-			name:       "Invalid length (too short)",
-			data:       []byte{0x10, 0x40, 0x01, 0x16},
-			wantValid:  false,
-			wantErrMsg: "SFrame length is not 5",
+			name:      "Invalid length (too long)",
+			data:      []byte{0x10, 0x40, 0x01, 0x41, 0x42, 0x16},
+			wantValid: false,
+			wantErr:   ErrFrameLength,
 		},
 		{
-			name:       "Invalid length (too long)",
-			data:       []byte{0x10, 0x40, 0x01, 0x41, 0x42, 0x16},
-			wantValid:  false,
-			wantErrMsg: "SFrame length is not 5",
+			name:      "Empty data",
+			data:      []byte{},
+			wantValid: false,
+			wantErr:   ErrFrameStart,
 		},
 	}
 
@@ -67,12 +80,8 @@ func TestSFrame_Verify(t *testing.T) {
 				t.Errorf("Verify() valid = %v, want %v", got, tt.wantValid)
 			}
 
-			if (err != nil) != (tt.wantErrMsg != "") {
-				t.Errorf("Verify() error = %v, wantErr %v", err, tt.wantErrMsg != "")
-			}
-
-			if err != nil && err.Error() != tt.wantErrMsg {
-				t.Errorf("Verify() error = %v, wantErrMsg %v", err.Error(), tt.wantErrMsg)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
 			}
 		})
 	}
@@ -0,0 +1,68 @@
+package mbus
+
+import (
+	"fmt"
+	"time"
+)
+
+// COMMAND_REQ_UD1 Request class 1 data (alarms) from the slave in a short frame
+func COMMAND_REQ_UD1(deviceAddress uint) []byte {
+	frame := EncodeSFrame(CFIELD_REQ_UD1_0, AField(deviceAddress))
+	return frame.Bytes()
+}
+
+// COMMAND_REQ_UD1_1 Request class 1 data from the slave with FCB set
+func COMMAND_REQ_UD1_1(deviceAddress uint) []byte {
+	frame := EncodeSFrame(CFIELD_REQ_UD1_1, AField(deviceAddress))
+	return frame.Bytes()
+}
+
+// readAlarm sends REQ_UD1. A slave without class 1 data answers with an ACK, a
+// slave with an alarm with RSP_UD CI 0x71 (alarm status) or CI 0x72 (variable data).
+// fcb selects the frame count bit, it must toggle between successful requests.
+func readAlarm(t Transport, deviceAddress uint, fcb bool, timing Timing) (AlarmState, error) {
+	state := AlarmState{Address: int(deviceAddress), Timestamp: time.Now()}
+	command := COMMAND_REQ_UD1(deviceAddress)
+	if fcb {
+		command = COMMAND_REQ_UD1_1(deviceAddress)
+	}
+
+	rawData, response, err := sendRequest(t, command, timing)
+	if err != nil {
+		return state, err
+	}
+	switch response {
+	case ResponseNone:
+		return state, ErrNoResponse
+	case ResponseAck:
+		return state, nil
+	}
+
+	frame := NewLFrame(rawData)
+	if _, err := frame.Verify(); err != nil {
+		return state, err
+	}
+	// Short and control frames carry no data
+	if len(rawData) < 9 {
+		return state, fmt.Errorf("%w: answer without data", ErrFrameLength)
+	}
+	ci, _ := frame.CIField()
+	state.CIField = ci
+	state.Data = append([]byte{}, rawData[7:len(rawData)-2]...)
+
+	switch CIField(ci) {
+	case CiFieldAlarm:
+		if len(state.Data) > 0 {
+			state.Status = state.Data[0]
+		}
+	case CiFieldVariable72:
+		if len(rawData) < 21 {
+			return state, fmt.Errorf("%w: telegram has no variable data header", ErrFrameLength)
+		}
+		state.Status = rawData[16]
+	default:
+		return state, fmt.Errorf("%w: 0x%02X", ErrUnsupportedCIField, ci)
+	}
+	state.Alarm = state.Status != 0
+	return state, nil
+}
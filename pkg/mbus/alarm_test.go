@@ -0,0 +1,114 @@
+package mbus
+
+import (
+	"errors"
+	"testing"
+)
+
+// alarmSlave answers REQ_UD1 with an ACK at address 1, an alarm report at address 2,
+// a variable data telegram at address 3 and an application error report at address 4.
+// It sends every request to requests.
+func alarmSlave(t *testing.T, requests chan<- []byte) Transport {
+	tr, slave := NewPipeTransport()
+	fakeSlave(t, slave, func(request []byte) []byte {
+		if requests != nil {
+			requests <- request
+		}
+		if len(request) != 5 {
+			return nil
+		}
+		var reply []byte
+		switch request[2] {
+		case 1:
+			return []byte{FRAME_ACK_START}
+		case 2:
+			reply = []byte{0x68, 0x04, 0x04, 0x68, 0x08, 0x02, CiFieldAlarm, 0x05, 0x00, 0x16}
+		case 3:
+			reply = append([]byte{}, testRspUd...)
+			reply[5] = 3
+			reply[16] = 0x08
+		case 4:
+			reply = []byte{0x68, 0x04, 0x04, 0x68, 0x08, 0x04, CiFieldApplicationErrors, 0x01, 0x00, 0x16}
+		default:
+			return nil
+		}
+		reply[len(reply)-2] = checksum(reply[4 : len(reply)-2])
+		return reply
+	})
+	return tr
+}
+
+func TestReadAlarmWith(t *testing.T) {
+	tests := []struct {
+		name       string
+		address    int
+		wantAlarm  bool
+		wantStatus byte
+		wantErr    string
+	}{
+		{name: "No alarm", address: 1},
+		{name: "Alarm report", address: 2, wantAlarm: true, wantStatus: 0x05},
+		{name: "Variable data with error status", address: 3, wantAlarm: true, wantStatus: 0x08},
+		{name: "Unsupported answer", address: 4, wantErr: "unsupported CI field: 0x70"},
+		{name: "No slave", address: 5, wantErr: ErrNoResponse.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := alarmSlave(t, nil)
+			defer tr.Close()
+
+			state, _ := ReadAlarmWith(tr, tt.address, false)
+			if state.Error != tt.wantErr {
+				t.Fatalf("ReadAlarmWith() error = %q, want %q", state.Error, tt.wantErr)
+			}
+			if state.Alarm != tt.wantAlarm || state.Status != tt.wantStatus {
+				t.Errorf("ReadAlarmWith() = alarm %v status 0x%02X, want alarm %v status 0x%02X",
+					state.Alarm, state.Status, tt.wantAlarm, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestBus_ReadAlarm(t *testing.T) {
+	requests := make(chan []byte, 4)
+	bus := NewBus(alarmSlave(t, requests))
+	defer bus.Close()
+
+	for i, want := range []CField{CFIELD_REQ_UD1_0, CFIELD_REQ_UD1_1, CFIELD_REQ_UD1_0} {
+		state, err := bus.ReadAlarm(2)
+		if err != nil || !state.Alarm {
+			t.Fatalf("ReadAlarm() = %+v, %v, want alarm", state, err)
+		}
+		if request := <-requests; request[1] != want.getByte() {
+			t.Errorf("request %d has C field 0x%02X, want 0x%02X", i, request[1], want.getByte())
+		}
+	}
+
+	if _, err := bus.ReadAlarm(5); !errors.Is(err, ErrNoResponse) {
+		t.Errorf("ReadAlarm(5) error = %v, want %v", err, ErrNoResponse)
+	}
+}
+
+func TestReadAlarmWith_FCB(t *testing.T) {
+	requests := make(chan []byte, 4)
+	tr := alarmSlave(t, requests)
+	defer tr.Close()
+
+	fcb := false
+	for i, want := range []CField{CFIELD_REQ_UD1_0, CFIELD_REQ_UD1_1, CFIELD_REQ_UD1_0} {
+		var state AlarmState
+		state, fcb = ReadAlarmWith(tr, 2, fcb)
+		if state.Error != "" {
+			t.Fatalf("ReadAlarmWith() %d error = %s", i, state.Error)
+		}
+		if request := <-requests; request[1] != want.getByte() {
+			t.Errorf("request %d C field = 0x%02X, want 0x%02X", i, request[1], want.getByte())
+		}
+	}
+
+	// Without an answer the request is repeated with the same bit
+	if _, next := ReadAlarmWith(tr, 5, true); !next {
+		t.Errorf("ReadAlarmWith() without answer toggled the frame count bit")
+	}
+}
@@ -0,0 +1,116 @@
+package mbus
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrUnsupportedBaudRate is returned for a baud rate M-Bus does not define
+var ErrUnsupportedBaudRate = errors.New("unsupported baud rate")
+
+// ErrBaudRateFixed is returned when the transport can not change its baud rate, e.g. a TCP gateway
+var ErrBaudRateFixed = errors.New("transport can not change the baud rate")
+
+// COMMAND_SET_BAUDRATE Switch the slave to the baud rate in a control frame
+func COMMAND_SET_BAUDRATE(deviceAddress uint, baud int) ([]byte, error) {
+	ci, ok := BaudRateCIField(baud)
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedBaudRate, baud)
+	}
+	frame := EncodeCFrame(CFIELD_SND_UD_0, AField(deviceAddress), ci)
+	return frame.Bytes(), nil
+}
+
+// switchBaudRate sends the baud rate command at the current rate and waits for the ACK.
+// The slave answers at the old rate and uses the new one from the next request on.
+// Nothing is sent if the transport can not follow the slave to the new rate.
+func switchBaudRate(t Transport, deviceAddress uint, baud int, timing Timing) error {
+	command, err := COMMAND_SET_BAUDRATE(deviceAddress, baud)
+	if err != nil {
+		return err
+	}
+	if _, err := transportBaud(t); err != nil {
+		return err
+	}
+	_, response, err := sendRequest(t, command, timing)
+	if err != nil {
+		return err
+	}
+	if response != ResponseAck {
+		return ErrNoAck
+	}
+	return nil
+}
+
+// BaudRates are the baud rates of the CiFieldBaudrate* commands. Automatic detection
+// tries them in this order.
+var BaudRates = []int{300, 1200, 2400, 4800, 9600, 19200, 38400}
+
+// transportBaud returns the function that sets the baud rate of the transport, or
+// ErrBaudRateFixed if the transport can not change it
+func transportBaud(t Transport) (func(baud int) error, error) {
+	if ct, ok := t.(*contextTransport); ok {
+		return transportBaud(ct.Transport)
+	}
+	setter, ok := t.(BaudRateSetter)
+	if !ok {
+		return nil, ErrBaudRateFixed
+	}
+	return setter.SetBaudRate, nil
+}
+
+// detectBaudRate sends SND_NKE to the address at each of the rates and returns the
+// first rate the slave acknowledges. Several slaves answering at a rate are reported
+// as ErrCollision together with the rate. setBaud switches the transport.
+func detectBaudRate(t Transport, address uint, rates []int, setBaud func(baud int) error) (int, error) {
+	for _, baud := range rates {
+		if err := setBaud(baud); err != nil {
+			return 0, err
+		}
+		response, err := probeAddress(t, address, TimingForBaud(baud))
+		switch {
+		case response == ResponseAck:
+			return baud, nil
+		case response == ResponseCollision:
+			return baud, ErrCollision
+		case err != nil:
+			return 0, err
+		}
+	}
+	return 0, ErrNoResponse
+}
+
+// scanBaudRates pings the addresses from first to last at each of the rates. A slave
+// is reported at the first rate it answers and is not pinged at the other rates.
+func scanBaudRates(t Transport, first int, last int, rates []int, setBaud func(baud int) error) ([]PingState, error) {
+	var found []PingState
+	answered := make(map[int]bool)
+	for _, baud := range rates {
+		if err := setBaud(baud); err != nil {
+			return found, err
+		}
+		timing := TimingForBaud(baud)
+		for address := first; address <= last; address++ {
+			if answered[address] {
+				continue
+			}
+			response, err := probeAddress(t, uint(address), timing)
+			if err != nil && response != ResponseCollision {
+				return found, err
+			}
+			if response == ResponseNone {
+				continue
+			}
+			answered[address] = true
+			found = append(found, PingState{
+				Address:   address,
+				State:     response == ResponseAck,
+				Collision: response == ResponseCollision,
+				BaudRate:  baud,
+				Timestamp: time.Now(),
+			})
+		}
+	}
+	return found, nil
+}
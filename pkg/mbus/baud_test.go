@@ -0,0 +1,269 @@
+package mbus
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+)
+
+// baudTransport is a pipe transport that records the baud rate changes
+type baudTransport struct {
+	Transport
+	mu    sync.Mutex
+	rates []int
+}
+
+func (bt *baudTransport) SetBaudRate(baud int) error {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+	bt.rates = append(bt.rates, baud)
+	return nil
+}
+
+// current returns the last baud rate set, 2400 before the first change
+func (bt *baudTransport) current() int {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+	if len(bt.rates) == 0 {
+		return defaultBaud
+	}
+	return bt.rates[len(bt.rates)-1]
+}
+
+// rateSlave simulates slaves at the baud rates given by address. A slave only
+// acknowledges SND_NKE while the transport is set to its rate.
+func rateSlave(t *testing.T, conn net.Conn, bt *baudTransport, rates map[byte]int) {
+	fakeSlave(t, conn, func(request []byte) []byte {
+		if len(request) == 5 && rates[request[2]] == bt.current() {
+			return []byte{FRAME_ACK_START}
+		}
+		return nil
+	})
+}
+
+// baudSlave acknowledges SND_NKE and the baud rate command at address 1 and 2
+func baudSlave(t *testing.T, conn net.Conn) {
+	fakeSlave(t, conn, func(request []byte) []byte {
+		switch {
+		case len(request) == 5 && (request[2] == 1 || request[2] == 2):
+			return []byte{FRAME_ACK_START}
+		case len(request) == 9 && request[5] == 1:
+			if _, ok := CIField(request[6]).BaudRate(); ok {
+				return []byte{FRAME_ACK_START}
+			}
+		}
+		return nil
+	})
+}
+
+func TestCOMMAND_SET_BAUDRATE(t *testing.T) {
+	got, err := COMMAND_SET_BAUDRATE(1, 9600)
+	if err != nil {
+		t.Fatalf("COMMAND_SET_BAUDRATE() error = %v", err)
+	}
+	want := []byte{0x68, 0x03, 0x03, 0x68, 0x53, 0x01, 0xBD, 0x11, 0x16}
+	if !bytes.Equal(got, want) {
+		t.Errorf("COMMAND_SET_BAUDRATE() = % X, want % X", got, want)
+	}
+
+	if _, err := COMMAND_SET_BAUDRATE(1, 600); !errors.Is(err, ErrUnsupportedBaudRate) {
+		t.Errorf("COMMAND_SET_BAUDRATE(600) error = %v, want %v", err, ErrUnsupportedBaudRate)
+	}
+}
+
+func TestBus_SwitchBaudRate(t *testing.T) {
+	pipe, slave := NewPipeTransport()
+	baudSlave(t, slave)
+	tr := &baudTransport{Transport: pipe}
+	bus := NewBus(tr)
+	defer bus.Close()
+
+	if err := bus.SwitchBaudRate(1, 9600); err != nil {
+		t.Fatalf("SwitchBaudRate() error = %v", err)
+	}
+	// The command is sent at the old rate
+	if len(tr.rates) != 0 {
+		t.Fatalf("SwitchBaudRate() changed the transport to %v, want no change", tr.rates)
+	}
+
+	if _, err := bus.Ping(1); err != nil {
+		t.Fatalf("Ping(1) error = %v", err)
+	}
+	if _, err := bus.Ping(2); err != nil {
+		t.Fatalf("Ping(2) error = %v", err)
+	}
+	if _, err := bus.Ping(2); err != nil {
+		t.Fatalf("Ping(2) error = %v", err)
+	}
+
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	want := []int{9600, 2400}
+	if len(tr.rates) != len(want) || tr.rates[0] != want[0] || tr.rates[1] != want[1] {
+		t.Errorf("transport rates = %v, want %v", tr.rates, want)
+	}
+}
+
+func TestBus_SetBaudRate(t *testing.T) {
+	tests := []struct {
+		name    string
+		baud    int
+		setter  bool
+		wantErr error
+	}{
+		{name: "Serial port", baud: 9600, setter: true},
+		{name: "Fixed rate transport", baud: 9600, wantErr: ErrBaudRateFixed},
+		{name: "Unsupported rate", baud: 600, setter: true, wantErr: ErrUnsupportedBaudRate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipe, slave := NewPipeTransport()
+			baudSlave(t, slave)
+			var tr Transport = pipe
+			if tt.setter {
+				tr = &baudTransport{Transport: pipe}
+			}
+			bus := NewBus(tr)
+			defer bus.Close()
+
+			if err := bus.SetBaudRate(tt.baud); !errors.Is(err, tt.wantErr) {
+				t.Errorf("SetBaudRate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSwitchBaudRateWith(t *testing.T) {
+	pipe, slave := NewPipeTransport()
+	defer pipe.Close()
+	baudSlave(t, slave)
+	tr := &baudTransport{Transport: pipe}
+
+	if err := SwitchBaudRateWith(tr, 1, 9600); err != nil {
+		t.Fatalf("SwitchBaudRateWith() error = %v", err)
+	}
+	if len(tr.rates) != 1 || tr.rates[0] != 9600 {
+		t.Errorf("transport rates = %v, want [9600]", tr.rates)
+	}
+
+	if err := SwitchBaudRateWith(tr, 3, 9600); !errors.Is(err, ErrNoAck) {
+		t.Errorf("SwitchBaudRateWith() at address 3 error = %v, want %v", err, ErrNoAck)
+	}
+}
+
+func TestSwitchBaudRate_FixedRate(t *testing.T) {
+	pipe, slave := NewPipeTransport()
+	var requests int
+	fakeSlave(t, slave, func(request []byte) []byte {
+		if len(request) == 9 {
+			requests++
+		}
+		return []byte{FRAME_ACK_START}
+	})
+
+	if err := SwitchBaudRateWith(pipe, 1, 9600); !errors.Is(err, ErrBaudRateFixed) {
+		t.Errorf("SwitchBaudRateWith() error = %v, want %v", err, ErrBaudRateFixed)
+	}
+
+	bus := NewBus(pipe)
+	defer bus.Close()
+	if err := bus.SwitchBaudRate(1, 9600); !errors.Is(err, ErrBaudRateFixed) {
+		t.Errorf("Bus.SwitchBaudRate() error = %v, want %v", err, ErrBaudRateFixed)
+	}
+	// The slave stays at the bus rate
+	if alive, err := bus.Ping(1); !alive || err != nil {
+		t.Errorf("Ping(1) = %v, %v, want true", alive, err)
+	}
+	if requests != 0 {
+		t.Errorf("slave received %d baud rate commands, want none", requests)
+	}
+}
+
+func TestDetectBaudRate(t *testing.T) {
+	tests := []struct {
+		name    string
+		address uint
+		want    int
+		wantErr error
+	}{
+		{name: "Default rate", address: 1, want: 2400},
+		{name: "Higher rate", address: 2, want: 9600},
+		{name: "No slave", address: 3, wantErr: ErrNoResponse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipe, slave := NewPipeTransport()
+			defer pipe.Close()
+			tr := &baudTransport{Transport: pipe}
+			rateSlave(t, slave, tr, map[byte]int{1: 2400, 2: 9600})
+
+			got, err := detectBaudRate(tr, tt.address, []int{2400, 9600, 19200}, tr.SetBaudRate)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("detectBaudRate() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("detectBaudRate() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanBaudRates(t *testing.T) {
+	pipe, slave := NewPipeTransport()
+	defer pipe.Close()
+	tr := &baudTransport{Transport: pipe}
+	rateSlave(t, slave, tr, map[byte]int{1: 9600, 2: 2400, 4: 19200})
+
+	found, err := scanBaudRates(tr, 1, 4, []int{2400, 9600, 19200}, tr.SetBaudRate)
+	if err != nil {
+		t.Fatalf("scanBaudRates() error = %v", err)
+	}
+	want := []PingState{
+		{Address: 2, State: true, BaudRate: 2400},
+		{Address: 1, State: true, BaudRate: 9600},
+		{Address: 4, State: true, BaudRate: 19200},
+	}
+	if len(found) != len(want) {
+		t.Fatalf("scanBaudRates() found %d slaves, want %d", len(found), len(want))
+	}
+	for i := range want {
+		if found[i].Address != want[i].Address || found[i].State != want[i].State || found[i].BaudRate != want[i].BaudRate {
+			t.Errorf("scanBaudRates()[%d] = %+v, want %+v", i, found[i], want[i])
+		}
+	}
+}
+
+func TestBus_DetectBaudRate(t *testing.T) {
+	pipe, slave := NewPipeTransport()
+	tr := &baudTransport{Transport: pipe}
+	rateSlave(t, slave, tr, map[byte]int{1: 300, 2: 2400})
+	bus := NewBus(tr)
+	defer bus.Close()
+
+	baud, err := bus.DetectBaudRate(1)
+	if err != nil || baud != 300 {
+		t.Fatalf("DetectBaudRate(1) = %d, %v, want 300", baud, err)
+	}
+
+	// The bus switches between the detected rate of slave 1 and the bus rate
+	if alive, err := bus.Ping(2); !alive || err != nil {
+		t.Errorf("Ping(2) = %v, %v, want true", alive, err)
+	}
+	if alive, err := bus.Ping(1); !alive || err != nil {
+		t.Errorf("Ping(1) = %v, %v, want true", alive, err)
+	}
+}
+
+func TestDetectBaudRateWith_FixedRate(t *testing.T) {
+	tr, slave := NewPipeTransport()
+	defer tr.Close()
+	baudSlave(t, slave)
+
+	if _, err := DetectBaudRateWith(tr, 1); !errors.Is(err, ErrBaudRateFixed) {
+		t.Errorf("DetectBaudRateWith() error = %v, want %v", err, ErrBaudRateFixed)
+	}
+}
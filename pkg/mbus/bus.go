@@ -0,0 +1,584 @@
+package mbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrBusClosed is returned by Bus methods after Close
+var ErrBusClosed = errors.New("bus is closed")
+
+// Bus is a long-lived session on one M-Bus segment. It keeps the transport
+// open and serialises all requests through an internal queue, so it can be
+// used from several goroutines.
+type Bus struct {
+	transport Transport
+	// baud is the baud rate of the bus, deviceBaud of slaves that were switched
+	// to another rate and current the rate the transport is set to
+	baud       int
+	deviceBaud map[int]int
+	current    int
+	// alarmFCB is the frame count bit of the next REQ_UD1 per slave, readFCB of the next REQ_UD2
+	alarmFCB map[int]bool
+	readFCB  map[int]bool
+	// retry is the retry policy of the bus, deviceRetry of slaves with their own policy
+	retry       RetryPolicy
+	deviceRetry map[int]RetryPolicy
+
+	queue     chan busRequest
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// busRequest is one request / response cycle executed by the bus worker
+type busRequest struct {
+	ctx    context.Context
+	fn     func(t Transport) error
+	result chan error
+}
+
+// OpenBus opens the port (see OpenTransport) and starts a bus session on it.
+func OpenBus(port string) (*Bus, error) {
+	return OpenBusContext(context.Background(), port)
+}
+
+// OpenBusContext is OpenBus that stops waiting for the port when the context is done.
+// The context is not used by the bus session itself.
+func OpenBusContext(ctx context.Context, port string) (*Bus, error) {
+	t, err := openTransportWaitContext(ctx, port)
+	if err != nil {
+		return nil, err
+	}
+	return NewBus(t), nil
+}
+
+// NewBus starts a bus session on an open transport. The bus takes ownership
+// of the transport and closes it in Close. The bus runs at the rate of a
+// BaudRateGetter transport and at 2400 baud otherwise, see NewBusAt.
+func NewBus(t Transport) *Bus {
+	baud := defaultBaud
+	if getter, ok := t.(BaudRateGetter); ok {
+		if rate, err := getter.BaudRate(); err == nil {
+			if _, ok := BaudRateCIField(rate); ok {
+				baud = rate
+			}
+		}
+	}
+	return newBus(t, baud)
+}
+
+// NewBusAt starts a bus session on an open transport that is set to the baud
+// rate, e.g. a TCP converter configured for 9600 baud.
+func NewBusAt(t Transport, baud int) (*Bus, error) {
+	if _, ok := BaudRateCIField(baud); !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedBaudRate, baud)
+	}
+	return newBus(t, baud), nil
+}
+
+func newBus(t Transport, baud int) *Bus {
+	b := &Bus{
+		transport:   t,
+		baud:        baud,
+		deviceBaud:  make(map[int]int),
+		alarmFCB:    make(map[int]bool),
+		readFCB:     make(map[int]bool),
+		retry:       DefaultRetryPolicy(),
+		deviceRetry: make(map[int]RetryPolicy),
+		current:     baud,
+		queue:       make(chan busRequest),
+		done:        make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+func (b *Bus) run() {
+	for {
+		select {
+		case req := <-b.queue:
+			t, release := withContext(req.ctx, b.transport)
+			err := req.fn(t)
+			release()
+			// A late answer to a canceled request must not be taken as the answer to the next one
+			if req.ctx.Err() != nil {
+				drain(b.transport, Timing{InterCharacter: TimingForBaud(b.current).Response})
+			}
+			req.result <- err
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// do queues fn and waits until the worker has executed it. When the context is
+// done, a queued fn is dropped and a running fn is interrupted at the next read.
+func (b *Bus) do(ctx context.Context, fn func(t Transport) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	req := busRequest{ctx: ctx, fn: fn, result: make(chan error, 1)}
+	select {
+	case b.queue <- req:
+	case <-b.done:
+		return ErrBusClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-req.result:
+		return err
+	case <-ctx.Done():
+		// The worker stops fn at the next read and discards a late answer
+		select {
+		case err := <-req.result:
+			return err
+		default:
+			return ctx.Err()
+		}
+	}
+}
+
+// call runs fn like do and returns its value. The value is handed over with the
+// result, so a caller that stops waiting does not share it with the worker.
+func call[T any](ctx context.Context, b *Bus, fn func(t Transport) (T, error)) (T, error) {
+	values := make(chan T, 1)
+	err := b.do(ctx, func(t Transport) error {
+		value, err := fn(t)
+		values <- value
+		return err
+	})
+	select {
+	case value := <-values:
+		return value, err
+	default:
+		var zero T
+		return zero, err
+	}
+}
+
+// busBaud is passed to doAt instead of a slave address for requests at the baud rate of the bus
+const busBaud = -1
+
+// doAt queues fn like do. Before fn runs, the transport is switched to the baud
+// rate of the slave at address, or of the bus for busBaud, and fn gets the timing for it.
+// fn is repeated according to the retry policy of the slave or the bus for the kind of request.
+func (b *Bus) doAt(ctx context.Context, address int, kind requestKind, fn func(t Transport, timing Timing) error) error {
+	return b.do(ctx, func(t Transport) error {
+		return b.runAt(ctx, t, address, kind, fn)
+	})
+}
+
+// callAt runs fn like doAt and returns its value like call.
+func callAt[T any](ctx context.Context, b *Bus, address int, kind requestKind, fn func(t Transport, timing Timing) (T, error)) (T, error) {
+	return call(ctx, b, func(t Transport) (T, error) {
+		var value T
+		err := b.runAt(ctx, t, address, kind, func(t Transport, timing Timing) error {
+			var err error
+			value, err = fn(t, timing)
+			return err
+		})
+		return value, err
+	})
+}
+
+// runAt executes fn on the worker for doAt and callAt
+func (b *Bus) runAt(ctx context.Context, t Transport, address int, kind requestKind, fn func(t Transport, timing Timing) error) error {
+	baud, ok := b.deviceBaud[address]
+	if !ok {
+		baud = b.baud
+	}
+	if err := b.switchTransport(t, baud); err != nil {
+		return err
+	}
+	policy, ok := b.deviceRetry[address]
+	if !ok {
+		policy = b.retry
+	}
+	return policy.forRequest(kind).do(ctx, t, address, TimingForBaud(baud), fn)
+}
+
+// switchTransport sets the baud rate of the transport if it differs from the current one
+func (b *Bus) switchTransport(t Transport, baud int) error {
+	if baud == b.current {
+		return nil
+	}
+	setter, ok := t.(BaudRateSetter)
+	if !ok {
+		return ErrBaudRateFixed
+	}
+	if err := setter.SetBaudRate(baud); err != nil {
+		return err
+	}
+	b.current = baud
+	return nil
+}
+
+// Close stops the bus and closes the transport.
+func (b *Bus) Close() error {
+	b.closeOnce.Do(func() {
+		close(b.done)
+		b.closeErr = b.transport.Close()
+	})
+	return b.closeErr
+}
+
+// Ping sends SND_NKE to the address and reports whether the slave answered with an ACK.
+// Several slaves at the address are reported as ErrCollision.
+func (b *Bus) Ping(address int) (bool, error) {
+	return b.PingContext(context.Background(), address)
+}
+
+// PingContext is Ping that stops waiting for the answer when the context is done.
+func (b *Bus) PingContext(ctx context.Context, address int) (bool, error) {
+	response, err := b.ProbeContext(ctx, address)
+	return response == ResponseAck, err
+}
+
+// Probe sends SND_NKE to the address and classifies the answer: no response, ACK or collision.
+func (b *Bus) Probe(address int) (Response, error) {
+	return b.ProbeContext(context.Background(), address)
+}
+
+// ProbeContext is Probe that stops waiting for the answer when the context is done.
+func (b *Bus) ProbeContext(ctx context.Context, address int) (Response, error) {
+	return callAt(ctx, b, address, requestRead, func(t Transport, timing Timing) (Response, error) {
+		return probeAddress(t, uint(address), timing)
+	})
+}
+
+// ReadUD2 requests class 2 data (REQ_UD2) from the address and parses the RSP_UD answer.
+// The frame count bit continues from the last readout, so the slave starts again with its first telegram.
+func (b *Bus) ReadUD2(address int) (LFrameParsed, error) {
+	return b.ReadUD2Context(context.Background(), address)
+}
+
+// ReadUD2Context is ReadUD2 that stops waiting for the answer when the context is done.
+func (b *Bus) ReadUD2Context(ctx context.Context, address int) (LFrameParsed, error) {
+	return callAt(ctx, b, address, requestRead, func(t Transport, timing Timing) (LFrameParsed, error) {
+		data, fcb, err := readDeviceState(t, uint(address), b.readFCB[address], timing)
+		b.readFCB[address] = fcb
+		return data, err
+	})
+}
+
+// ReadSelected sends the record selectors (SND_UD) and requests class 2 data. A slave
+// that supports selection for readout returns only the selected records.
+func (b *Bus) ReadSelected(address int, selectors []RecordSelector) (LFrameParsed, error) {
+	return b.ReadSelectedContext(context.Background(), address, selectors)
+}
+
+// ReadSelectedContext is ReadSelected that stops waiting for the answer when the context is done.
+func (b *Bus) ReadSelectedContext(ctx context.Context, address int, selectors []RecordSelector) (LFrameParsed, error) {
+	return callAt(ctx, b, address, requestRead, func(t Transport, timing Timing) (LFrameParsed, error) {
+		data, fcb, err := readSelected(t, uint(address), selectors, b.readFCB[address], timing)
+		b.readFCB[address] = fcb
+		return data, err
+	})
+}
+
+// WriteRecords writes the data records to the slave with SND_UD and waits for the ACK.
+func (b *Bus) WriteRecords(address int, records []RecordWrite) error {
+	return b.WriteRecordsContext(context.Background(), address, records)
+}
+
+// WriteRecordsContext is WriteRecords that stops waiting for the ACK when the context is done.
+func (b *Bus) WriteRecordsContext(ctx context.Context, address int, records []RecordWrite) error {
+	return b.doAt(ctx, address, requestWrite, func(t Transport, timing Timing) error {
+		return writeRecords(t, uint(address), records, timing)
+	})
+}
+
+// ReadAlarm requests class 1 data (REQ_UD1) from the address and reports the alarm
+// status. The frame count bit toggles after every answer, so the slave does not
+// repeat an alarm it has already reported.
+func (b *Bus) ReadAlarm(address int) (AlarmState, error) {
+	return b.ReadAlarmContext(context.Background(), address)
+}
+
+// ReadAlarmContext is ReadAlarm that stops waiting for the answer when the context is done.
+func (b *Bus) ReadAlarmContext(ctx context.Context, address int) (AlarmState, error) {
+	return callAt(ctx, b, address, requestRead, func(t Transport, timing Timing) (AlarmState, error) {
+		state, err := readAlarm(t, uint(address), b.alarmFCB[address], timing)
+		if err == nil {
+			b.alarmFCB[address] = !b.alarmFCB[address]
+		}
+		return state, err
+	})
+}
+
+// SendUD sends user data (SND_UD) with the CI field to the address and waits for the ACK.
+func (b *Bus) SendUD(address int, ci CIField, data []byte) error {
+	return b.SendUDContext(context.Background(), address, ci, data)
+}
+
+// SendUDContext is SendUD that stops waiting for the ACK when the context is done.
+func (b *Bus) SendUDContext(ctx context.Context, address int, ci CIField, data []byte) error {
+	return b.doAt(ctx, address, requestWrite, func(t Transport, timing Timing) error {
+		return sendUserData(t, uint(address), ci, data, timing)
+	})
+}
+
+// ApplicationReset resets the application of the slave (SND_UD CI 0x50). The subcode
+// selects the data the slave returns on the next REQ_UD2.
+func (b *Bus) ApplicationReset(address int, subcode ResetSubcode) error {
+	return b.ApplicationResetContext(context.Background(), address, subcode)
+}
+
+// ApplicationResetContext is ApplicationReset that stops waiting for the ACK when the context is done.
+func (b *Bus) ApplicationResetContext(ctx context.Context, address int, subcode ResetSubcode) error {
+	return b.doAt(ctx, address, requestWrite, func(t Transport, timing Timing) error {
+		return applicationReset(t, uint(address), subcode, timing)
+	})
+}
+
+// SetDateTime writes the date and time to the slave as CP32 or CP48 time point (SND_UD, VIF 0x6D).
+func (b *Bus) SetDateTime(address int, dateTime time.Time, format TimePointType) error {
+	return b.SetDateTimeContext(context.Background(), address, dateTime, format)
+}
+
+// SetDateTimeContext is SetDateTime that stops waiting for the ACK when the context is done.
+func (b *Bus) SetDateTimeContext(ctx context.Context, address int, dateTime time.Time, format TimePointType) error {
+	return b.doAt(ctx, address, requestWrite, func(t Transport, timing Timing) error {
+		return setDateTime(t, uint(address), dateTime, format, timing)
+	})
+}
+
+// Synchronize broadcasts the date and time to all slaves (CI 0x54 to address 255). Slaves
+// with their own baud rate do not receive it, use SetDateTime for them.
+func (b *Bus) Synchronize(dateTime time.Time, format TimePointType) error {
+	return b.SynchronizeContext(context.Background(), dateTime, format)
+}
+
+// SynchronizeContext is Synchronize that does not send the broadcast when the context is done.
+func (b *Bus) SynchronizeContext(ctx context.Context, dateTime time.Time, format TimePointType) error {
+	return b.doAt(ctx, busBaud, requestWrite, func(t Transport, timing Timing) error {
+		return synchronize(t, dateTime, format, timing)
+	})
+}
+
+// SelectSecondary selects the slave with the secondary address. The selected slave
+// answers at address 253 until another slave is selected.
+func (b *Bus) SelectSecondary(address SecondaryAddress) error {
+	return b.SelectSecondaryContext(context.Background(), address)
+}
+
+// SelectSecondaryContext is SelectSecondary that stops waiting for the ACK when the context is done.
+func (b *Bus) SelectSecondaryContext(ctx context.Context, address SecondaryAddress) error {
+	return b.doAt(ctx, busBaud, requestRead, func(t Transport, timing Timing) error {
+		return selectSecondary(t, address, timing)
+	})
+}
+
+// ReadSecondary selects the slave with the secondary address and requests class 2 data at address 253.
+func (b *Bus) ReadSecondary(address SecondaryAddress) (LFrameParsed, error) {
+	return b.ReadSecondaryContext(context.Background(), address)
+}
+
+// ReadSecondaryContext is ReadSecondary that stops waiting for the answer when the context is done.
+func (b *Bus) ReadSecondaryContext(ctx context.Context, address SecondaryAddress) (LFrameParsed, error) {
+	return callAt(ctx, b, busBaud, requestRead, func(t Transport, timing Timing) (LFrameParsed, error) {
+		return readSecondaryDeviceState(t, address, timing)
+	})
+}
+
+// SearchSecondary finds all slaves on the bus by their secondary address. The bus is
+// blocked for other requests until the search is finished.
+func (b *Bus) SearchSecondary() (SecondarySearch, error) {
+	return b.SearchSecondaryContext(context.Background())
+}
+
+// SearchSecondaryContext is SearchSecondary that stops the search when the context is done.
+func (b *Bus) SearchSecondaryContext(ctx context.Context) (SecondarySearch, error) {
+	return callAt(ctx, b, busBaud, requestRead, func(t Transport, timing Timing) (SecondarySearch, error) {
+		return searchSecondary(t, timing)
+	})
+}
+
+// SetPrimaryAddress changes the primary address of the slave and checks that it answers at the new address.
+func (b *Bus) SetPrimaryAddress(address int, newAddress int) error {
+	return b.SetPrimaryAddressContext(context.Background(), address, newAddress)
+}
+
+// SetPrimaryAddressContext is SetPrimaryAddress that stops waiting for the answer when the context is done.
+func (b *Bus) SetPrimaryAddressContext(ctx context.Context, address int, newAddress int) error {
+	return b.doAt(ctx, address, requestOnce, func(t Transport, timing Timing) error {
+		if err := setPrimaryAddress(t, uint(address), uint(newAddress), timing); err != nil {
+			return err
+		}
+		b.moveDevice(address, newAddress)
+		return nil
+	})
+}
+
+// SetPrimaryAddressSecondary selects the slave by its secondary address and changes its primary address.
+func (b *Bus) SetPrimaryAddressSecondary(address SecondaryAddress, newAddress int) error {
+	return b.SetPrimaryAddressSecondaryContext(context.Background(), address, newAddress)
+}
+
+// SetPrimaryAddressSecondaryContext is SetPrimaryAddressSecondary that stops waiting for the answer when the context is done.
+func (b *Bus) SetPrimaryAddressSecondaryContext(ctx context.Context, address SecondaryAddress, newAddress int) error {
+	return b.doAt(ctx, busBaud, requestOnce, func(t Transport, timing Timing) error {
+		previous, err := setPrimaryAddressSecondary(t, address, uint(newAddress), timing)
+		if err != nil {
+			return err
+		}
+		b.moveDevice(int(previous), newAddress)
+		return nil
+	})
+}
+
+// moveDevice moves the state of the slave to its new primary address. The slave keeps
+// its baud rate, retry policy and frame count bits, whatever was kept for the new
+// address belonged to no slave.
+func (b *Bus) moveDevice(address int, newAddress int) {
+	if address == newAddress {
+		return
+	}
+	moveEntry(b.deviceBaud, address, newAddress)
+	moveEntry(b.deviceRetry, address, newAddress)
+	moveEntry(b.alarmFCB, address, newAddress)
+	moveEntry(b.readFCB, address, newAddress)
+}
+
+func moveEntry[V any](m map[int]V, address int, newAddress int) {
+	value, ok := m[address]
+	delete(m, address)
+	delete(m, newAddress)
+	if ok {
+		m[newAddress] = value
+	}
+}
+
+// Commission searches all meters by secondary address and assigns primary addresses
+// according to the plan. The bus is blocked for other requests until it is finished.
+func (b *Bus) Commission(plan CommissionPlan) (Inventory, error) {
+	return b.CommissionContext(context.Background(), plan)
+}
+
+// CommissionContext is Commission that stops the search and the remaining address changes when the context is done.
+func (b *Bus) CommissionContext(ctx context.Context, plan CommissionPlan) (Inventory, error) {
+	return callAt(ctx, b, busBaud, requestOnce, func(t Transport, timing Timing) (Inventory, error) {
+		return commission(t, plan, timing)
+	})
+}
+
+// SetBaudRate sets the baud rate of the bus. Slaves with their own rate set by
+// SetDeviceBaudRate or SwitchBaudRate keep it.
+func (b *Bus) SetBaudRate(baud int) error {
+	if _, ok := BaudRateCIField(baud); !ok {
+		return fmt.Errorf("%w: %d", ErrUnsupportedBaudRate, baud)
+	}
+	return b.do(context.Background(), func(t Transport) error {
+		if err := b.switchTransport(t, baud); err != nil {
+			return err
+		}
+		b.baud = baud
+		return nil
+	})
+}
+
+// SetDeviceBaudRate tells the bus that the slave at the address communicates at the
+// baud rate. The bus switches the transport to it for every request to the slave.
+func (b *Bus) SetDeviceBaudRate(address int, baud int) error {
+	if _, ok := BaudRateCIField(baud); !ok {
+		return fmt.Errorf("%w: %d", ErrUnsupportedBaudRate, baud)
+	}
+	return b.do(context.Background(), func(t Transport) error {
+		b.deviceBaud[address] = baud
+		return nil
+	})
+}
+
+// SetRetryPolicy sets the retry policy for all requests. Slaves with their own policy
+// set by SetDeviceRetryPolicy keep it.
+func (b *Bus) SetRetryPolicy(policy RetryPolicy) error {
+	return b.do(context.Background(), func(t Transport) error {
+		b.retry = policy
+		return nil
+	})
+}
+
+// SetDeviceRetryPolicy sets the retry policy for requests to the slave at the address,
+// e.g. more attempts for a meter at the far end of a long cable.
+func (b *Bus) SetDeviceRetryPolicy(address int, policy RetryPolicy) error {
+	return b.do(context.Background(), func(t Transport) error {
+		b.deviceRetry[address] = policy
+		return nil
+	})
+}
+
+// SwitchBaudRate commands the slave at the address to change its baud rate. The slave
+// acknowledges at the old rate, all following requests to it use the new rate.
+func (b *Bus) SwitchBaudRate(address int, baud int) error {
+	return b.SwitchBaudRateContext(context.Background(), address, baud)
+}
+
+// SwitchBaudRateContext is SwitchBaudRate that stops waiting for the ACK when the context is done.
+func (b *Bus) SwitchBaudRateContext(ctx context.Context, address int, baud int) error {
+	return b.doAt(ctx, address, requestOnce, func(t Transport, timing Timing) error {
+		if err := switchBaudRate(t, uint(address), baud, timing); err != nil {
+			return err
+		}
+		b.deviceBaud[address] = baud
+		return nil
+	})
+}
+
+// DetectBaudRate finds the baud rate of the slave at the address by sending SND_NKE at
+// each of BaudRates. The detected rate is used for all following requests to the slave.
+func (b *Bus) DetectBaudRate(address int) (int, error) {
+	return b.DetectBaudRateContext(context.Background(), address)
+}
+
+// DetectBaudRateContext is DetectBaudRate that stops trying the rates when the context is done.
+func (b *Bus) DetectBaudRateContext(ctx context.Context, address int) (int, error) {
+	return call(ctx, b, func(t Transport) (int, error) {
+		baud, err := detectBaudRate(t, uint(address), BaudRates, func(baud int) error {
+			return b.switchTransport(t, baud)
+		})
+		if err != nil {
+			return 0, err
+		}
+		b.setDeviceBaud(address, baud)
+		return baud, nil
+	})
+}
+
+// ScanBaudRates pings the addresses from first to last at each of BaudRates and records
+// the rate of every slave that answers. Slaves with a collision are reported but not
+// recorded. The bus is blocked for other requests until the scan is finished.
+func (b *Bus) ScanBaudRates(first int, last int) ([]PingState, error) {
+	return b.ScanBaudRatesContext(context.Background(), first, last)
+}
+
+// ScanBaudRatesContext is ScanBaudRates that stops the scan when the context is done.
+func (b *Bus) ScanBaudRatesContext(ctx context.Context, first int, last int) ([]PingState, error) {
+	if first < 0 || last > 250 || first > last {
+		return nil, fmt.Errorf("%w: %d-%d", ErrInvalidSlaveAddress, first, last)
+	}
+	return call(ctx, b, func(t Transport) ([]PingState, error) {
+		found, err := scanBaudRates(t, first, last, BaudRates, func(baud int) error {
+			return b.switchTransport(t, baud)
+		})
+		for _, ps := range found {
+			if ps.State {
+				b.setDeviceBaud(ps.Address, ps.BaudRate)
+			}
+		}
+		return found, err
+	})
+}
+
+// setDeviceBaud records the baud rate of the slave, a slave at the rate of the bus needs no entry
+func (b *Bus) setDeviceBaud(address int, baud int) {
+	if baud == b.baud {
+		delete(b.deviceBaud, address)
+		return
+	}
+	b.deviceBaud[address] = baud
+}
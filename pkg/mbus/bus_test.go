@@ -0,0 +1,214 @@
+package mbus
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestBus_Ping(t *testing.T) {
+	tr, slave := NewPipeTransport()
+	bus := NewBus(tr)
+	defer bus.Close()
+
+	fakeSlave(t, slave, func(request []byte) []byte {
+		if bytes.Equal(request, COMMAND_SND_NKE(5)) {
+			return []byte{FRAME_ACK_START}
+		}
+		return nil
+	})
+
+	tests := []struct {
+		name    string
+		address int
+		want    bool
+	}{
+		{name: "Device answers with ACK", address: 5, want: true},
+		{name: "No device at address", address: 6, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bus.Ping(tt.address)
+			if err != nil {
+				t.Fatalf("Ping() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Ping() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBus_SerialisesRequests(t *testing.T) {
+	tr, slave := NewPipeTransport()
+	bus := NewBus(tr)
+	defer bus.Close()
+
+	var mu sync.Mutex
+	var broken int
+	fakeSlave(t, slave, func(request []byte) []byte {
+		if !bytes.Equal(request, COMMAND_SND_NKE(1)) {
+			mu.Lock()
+			broken++
+			mu.Unlock()
+			return nil
+		}
+		return []byte{FRAME_ACK_START}
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if alive, err := bus.Ping(1); err != nil || !alive {
+				t.Errorf("Ping() = %v, %v, want true, nil", alive, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if broken != 0 {
+		t.Errorf("slave received %d interleaved requests, want 0", broken)
+	}
+}
+
+func TestBus_ReadUD2(t *testing.T) {
+	tr, slave := NewPipeTransport()
+	bus := NewBus(tr)
+	defer bus.Close()
+
+	fakeSlave(t, slave, func(request []byte) []byte {
+		return testRspUd
+	})
+
+	data, err := bus.ReadUD2(1)
+	if err != nil {
+		t.Fatalf("ReadUD2() error = %v", err)
+	}
+	if data.Manufacturer != "PAD" {
+		t.Errorf("ReadUD2() Manufacturer = %v, want %v", data.Manufacturer, "PAD")
+	}
+}
+
+func TestBus_SendUD(t *testing.T) {
+	tr, slave := NewPipeTransport()
+	bus := NewBus(tr)
+	defer bus.Close()
+
+	var request []byte
+	fakeSlave(t, slave, func(r []byte) []byte {
+		request = r
+		return []byte{FRAME_ACK_START}
+	})
+
+	if err := bus.SendUD(1, CiFieldDataSend, []byte{0x01, 0x7A, 0x02}); err != nil {
+		t.Fatalf("SendUD() error = %v", err)
+	}
+	want := []byte{0x68, 0x06, 0x06, 0x68, 0x53, 0x01, 0x51, 0x01, 0x7A, 0x02, 0x22, 0x16}
+	if !bytes.Equal(request, want) {
+		t.Errorf("SendUD() request = % X, want % X", request, want)
+	}
+}
+
+func TestBus_Close(t *testing.T) {
+	tr, slave := NewPipeTransport()
+	defer slave.Close()
+	bus := NewBus(tr)
+
+	if err := bus.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, err := bus.Ping(1); !errors.Is(err, ErrBusClosed) {
+		t.Errorf("Ping() after Close error = %v, want %v", err, ErrBusClosed)
+	}
+}
+
+func TestBus_ReadUD2_FrameCountBit(t *testing.T) {
+	pages := [][]byte{
+		testTelegram(t, 0x01, 0x0C, 0x13, 0x11, 0x00, 0x00, 0x00, 0x1F),
+		testTelegram(t, 0x02, 0x0C, 0x13, 0x22, 0x00, 0x00, 0x00, 0x1F),
+		testTelegram(t, 0x03, 0x0C, 0x13, 0x33, 0x00, 0x00, 0x00),
+	}
+	tr, slave := NewPipeTransport()
+	// The slave repeats its last telegram when the FCB did not toggle and starts
+	// again with the first one after the last
+	var last *bool
+	page := -1
+	fakeSlave(t, slave, func(request []byte) []byte {
+		if len(request) != 5 || request[2] != 1 {
+			return nil
+		}
+		fcb := request[1] == CFIELD_REQ_UD2_1.getByte()
+		if last == nil || *last != fcb {
+			page = (page + 1) % len(pages)
+		}
+		last = &fcb
+		return pages[page]
+	})
+	bus := NewBus(tr)
+	defer bus.Close()
+
+	for i := 0; i < 2; i++ {
+		data, err := bus.ReadUD2(1)
+		if err != nil {
+			t.Fatalf("ReadUD2() %d error = %v", i, err)
+		}
+		if len(data.Records) != 3 || len(data.Telegrams) != 3 {
+			t.Errorf("ReadUD2() %d got %d records in %d telegrams, want 3 in 3", i, len(data.Records), len(data.Telegrams))
+		}
+	}
+}
+
+// fixedRateTransport is a transport that reports the baud rate it is set to
+type fixedRateTransport struct {
+	Transport
+	baud int
+}
+
+func (f fixedRateTransport) BaudRate() (int, error) {
+	return f.baud, nil
+}
+
+func TestNewBusAt(t *testing.T) {
+	tests := []struct {
+		name    string
+		bus     func(t Transport) (*Bus, error)
+		wantErr error
+	}{
+		{name: "Rate as parameter", bus: func(t Transport) (*Bus, error) { return NewBusAt(t, 9600) }},
+		{name: "Rate of the transport", bus: func(t Transport) (*Bus, error) {
+			return NewBus(fixedRateTransport{Transport: t, baud: 9600}), nil
+		}},
+		{name: "Unsupported rate", bus: func(t Transport) (*Bus, error) { return NewBusAt(t, 9601) }, wantErr: ErrUnsupportedBaudRate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, slave := NewPipeTransport()
+			bus, err := tt.bus(tr)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("bus error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				tr.Close()
+				return
+			}
+			defer bus.Close()
+			fakeSlave(t, slave, func(request []byte) []byte {
+				return []byte{FRAME_ACK_START}
+			})
+
+			// The pipe has a fixed rate, the bus must not try to switch it
+			if err := bus.SetDeviceBaudRate(5, 9600); err != nil {
+				t.Fatalf("SetDeviceBaudRate() error = %v", err)
+			}
+			if alive, err := bus.Ping(5); !alive || err != nil {
+				t.Errorf("Ping() = %v, %v, want true", alive, err)
+			}
+		})
+	}
+}
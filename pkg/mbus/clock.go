@@ -0,0 +1,78 @@
+package mbus
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimePointType selects the encoding of the date and time written to a slave
+type TimePointType int
+
+const (
+	// TimePointCP32 Type F: Compound CP32, date and time to the minute (DIF 0x04)
+	TimePointCP32 TimePointType = iota
+	// TimePointCP48 Type I: Compound CP48, date and time to the second (DIF 0x06)
+	TimePointCP48
+)
+
+// dateTimeRecord encodes t as data record with VIF 0x6D (date and time)
+func dateTimeRecord(t time.Time, format TimePointType) ([]byte, error) {
+	switch format {
+	case TimePointCP32:
+		value, err := EncodeTimePointCP32(t)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{0x04, 0x6D}, value...), nil
+	case TimePointCP48:
+		value, err := EncodeTimePointCP48(t)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{0x06, 0x6D}, value...), nil
+	}
+	return nil, fmt.Errorf("unknown time point type %d", format)
+}
+
+// COMMAND_SET_DATETIME Write the date and time to the slave with SND_UD CI 0x51
+func COMMAND_SET_DATETIME(deviceAddress uint, t time.Time, format TimePointType) ([]byte, error) {
+	data, err := dateTimeRecord(t, format)
+	if err != nil {
+		return nil, err
+	}
+	return COMMAND_SND_UD(deviceAddress, CiFieldDataSend, data)
+}
+
+// COMMAND_SYNCHRONIZE Send the date and time with SND_UD CI 0x54 (synchronize action) to
+// the broadcast address 255. The slaves set their clock and do not answer.
+func COMMAND_SYNCHRONIZE(t time.Time, format TimePointType) ([]byte, error) {
+	data, err := dateTimeRecord(t, format)
+	if err != nil {
+		return nil, err
+	}
+	return COMMAND_SND_UD(uint(AFieldBroadcastAddress), CiFieldSynchronizeAction, data)
+}
+
+// setDateTime writes the date and time to the slave and waits for the ACK
+func setDateTime(t Transport, deviceAddress uint, dateTime time.Time, format TimePointType, timing Timing) error {
+	data, err := dateTimeRecord(dateTime, format)
+	if err != nil {
+		return err
+	}
+	return sendUserData(t, deviceAddress, CiFieldDataSend, data, timing)
+}
+
+// synchronize broadcasts the date and time. No slave answers, the response time is
+// waited anyway so the slaves have set their clock before the next request.
+func synchronize(t Transport, dateTime time.Time, format TimePointType, timing Timing) error {
+	command, err := COMMAND_SYNCHRONIZE(dateTime, format)
+	if err != nil {
+		return err
+	}
+	if _, err := t.Write(command); err != nil {
+		return err
+	}
+	// An answer to a broadcast can only be noise, it is discarded
+	_, _, _ = readResponse(t, timing)
+	return nil
+}
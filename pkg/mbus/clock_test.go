@@ -0,0 +1,84 @@
+package mbus
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCOMMAND_SET_DATETIME(t *testing.T) {
+	dateTime := time.Date(2012, 7, 10, 15, 25, 0, 0, time.UTC)
+	got, err := COMMAND_SET_DATETIME(1, dateTime, TimePointCP32)
+	if err != nil {
+		t.Fatalf("COMMAND_SET_DATETIME() error = %v", err)
+	}
+	want := []byte{0x68, 0x09, 0x09, 0x68, 0x53, 0x01, 0x51, 0x04, 0x6D, 0x19, 0x0F, 0x8A, 0x17, 0xDF, 0x16}
+	if !bytes.Equal(got, want) {
+		t.Errorf("COMMAND_SET_DATETIME() = % X, want % X", got, want)
+	}
+
+	got, err = COMMAND_SYNCHRONIZE(dateTime, TimePointCP48)
+	if err != nil {
+		t.Fatalf("COMMAND_SYNCHRONIZE() error = %v", err)
+	}
+	if got[5] != 0xFF || CIField(got[6]) != CiFieldSynchronizeAction || got[7] != 0x06 || got[8] != 0x6D {
+		t.Errorf("COMMAND_SYNCHRONIZE() = % X, want CP48 record to address 255 with CI 0x54", got)
+	}
+}
+
+func TestBus_SetDateTime(t *testing.T) {
+	tr, slave := NewPipeTransport()
+	received := make(chan []byte, 2)
+	fakeSlave(t, slave, func(request []byte) []byte {
+		received <- request
+		if request[5] == 1 {
+			return []byte{FRAME_ACK_START}
+		}
+		return nil
+	})
+	bus := NewBus(tr)
+	defer bus.Close()
+
+	dateTime := time.Date(2024, 5, 1, 8, 30, 15, 0, time.UTC)
+	if err := bus.SetDateTime(1, dateTime, TimePointCP48); err != nil {
+		t.Fatalf("SetDateTime() error = %v", err)
+	}
+	request := <-received
+	if value, _ := From48intTimePoint(request[9:15]); value != "2024-05-01T08:30:15Z" {
+		t.Errorf("slave received %s, want 2024-05-01T08:30:15Z", value)
+	}
+
+	// The broadcast is not acknowledged
+	if err := bus.Synchronize(dateTime, TimePointCP32); err != nil {
+		t.Fatalf("Synchronize() error = %v", err)
+	}
+	if request := <-received; request[5] != 0xFF {
+		t.Errorf("Synchronize() sent to address %d, want 255", request[5])
+	}
+
+	if err := bus.SetDateTime(1, time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), TimePointCP32); !errors.Is(err, ErrTimePointRange) {
+		t.Errorf("SetDateTime(1970) error = %v, want %v", err, ErrTimePointRange)
+	}
+}
+
+func TestDecode_TimePointCP48(t *testing.T) {
+	record, err := dateTimeRecord(time.Date(2024, 5, 1, 8, 30, 15, 0, time.UTC), TimePointCP48)
+	if err != nil {
+		t.Fatalf("dateTimeRecord() error = %v", err)
+	}
+	// The clock record as a meter returns it after SetDateTime
+	data := append(append([]byte{}, testRspUd[7:19]...), record...)
+	frame, err := EncodeLFrame(CFIELD_RSP_UD_a, NewAField(1), CiFieldVariable72, data)
+	if err != nil {
+		t.Fatalf("EncodeLFrame() error = %v", err)
+	}
+
+	telegram, err := Decode(frame.Bytes())
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if len(telegram.Records) != 1 || telegram.Records[0].Value != "2024-05-01T08:30:15Z" {
+		t.Errorf("Decode() records = %+v, want 2024-05-01T08:30:15Z", telegram.Records)
+	}
+}
@@ -1,11 +1,15 @@
 package mbus
 
 import (
-	"github.com/tarm/serial"
-	"io"
+	"context"
+	"errors"
+	"fmt"
 	"time"
 )
 
+// defaultBaud is the baud rate of M-Bus slaves out of the box
+const defaultBaud = 2400
+
 const (
 	rtuMaxSize               = 256
 	FRAME_ACK_START     byte = 0xE5
@@ -15,61 +19,63 @@ const (
 	FRAME_STOP          byte = 0x16
 )
 
+// COMMAND_SND_NKE Initialize the slave in a short frame
 func COMMAND_SND_NKE(deviceAddress uint) []byte {
-	var b = []byte{}
-	var cf = CFIELD_SND_NKE.getByte()
-	var ad = byte(deviceAddress)
-	var crc = cf + ad // Arithmetic checksum
-	b = append(b, FRAME_SHORT_START)
-	b = append(b, cf)
-	b = append(b, ad)
-	b = append(b, crc)
-	b = append(b, FRAME_STOP)
-	return b
+	frame := EncodeSFrame(CFIELD_SND_NKE, AField(deviceAddress))
+	return frame.Bytes()
 }
 
-// COMMAND_REQ_UD2
+// COMMAND_REQ_UD2 Request class 2 data from the slave in a short frame
 func COMMAND_REQ_UD2(deviceAddress uint) []byte {
-	var b []byte
-	var cf = CFIELD_REQ_UD2_0.getByte()
-	var ad = byte(deviceAddress)
-	var crc = cf + ad // Arithmetic checksum
-	b = append(b, FRAME_SHORT_START)
-	b = append(b, cf)
-	b = append(b, ad)
-	b = append(b, crc)
-	b = append(b, FRAME_STOP)
-	return b
+	frame := EncodeSFrame(CFIELD_REQ_UD2_0, AField(deviceAddress))
+	return frame.Bytes()
+}
+
+// COMMAND_REQ_UD2_1 Request class 2 data from the slave with FCB set, used for
+// every second request of a multi-telegram readout
+func COMMAND_REQ_UD2_1(deviceAddress uint) []byte {
+	frame := EncodeSFrame(CFIELD_REQ_UD2_1, AField(deviceAddress))
+	return frame.Bytes()
+}
+
+// COMMAND_SND_UD Send user data to the slave in a long frame
+func COMMAND_SND_UD(deviceAddress uint, ci CIField, data []byte) ([]byte, error) {
+	frame, err := EncodeLFrame(CFIELD_SND_UD_0, AField(deviceAddress), ci, data)
+	if err != nil {
+		return nil, err
+	}
+	return frame.Bytes(), nil
 }
 
-// sendDataRequest SendMessage Request Command to serial port and Device Address
-// If the port is in use by another application, it will retry until the port becomes available
-// or until the timeout is reached
-func sendDataRequest(serialPort string, deviceAddress uint, command []byte) ([]byte, error) {
+// portWait is how long the functions that open the port retry while another application
+// holds it, the deadline of the context replaces it. portRetryInterval is the pause
+// between the tries.
+const (
+	portWait          = 30 * time.Second
+	portRetryInterval = 1 * time.Second
+)
+
+// openTransportWaitContext opens the transport for the port string.
+// If the port is in use by another application, it will retry until the port becomes available,
+// portWait or the deadline of the context has passed or the context is done
+func openTransportWaitContext(ctx context.Context, port string) (Transport, error) {
 	// Maximum time to wait for the port to become available
-	maxWaitTime := 30 * time.Second
+	maxWaitTime := portWait
+	if deadline, ok := ctx.Deadline(); ok {
+		maxWaitTime = time.Until(deadline)
+	}
 	// Interval between retries
-	retryInterval := 1 * time.Second
+	retryInterval := portRetryInterval
 	// Start time to track timeout
 	startTime := time.Now()
 
-	config := serial.Config{
-		Name:        serialPort,
-		Baud:        2400,
-		Size:        8,
-		StopBits:    serial.Stop1,
-		Parity:      serial.ParityEven,
-		ReadTimeout: 2 * time.Second,
-	}
-
-	var port *serial.Port
-	var err error
-
-	// Try to open the port with retries if it's in use
 	for {
-		port, err = serial.OpenPort(&config)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		t, err := OpenTransport(port)
 		if err == nil {
-			break
+			return t, nil
 		}
 
 		// Check if the error is related to the port being in use
@@ -78,102 +84,117 @@ func sendDataRequest(serialPort string, deviceAddress uint, command []byte) ([]b
 			if time.Since(startTime) > maxWaitTime {
 				return nil, err
 			}
-			time.Sleep(retryInterval)
+			retry := time.NewTimer(retryInterval)
+			select {
+			case <-retry.C:
+			case <-ctx.Done():
+				retry.Stop()
+				return nil, ctx.Err()
+			}
 			continue
 		}
 
-		// For other errors, log and return
+		// For other errors, return
 		return nil, err
 	}
+}
 
-	defer func() {
-		err := port.Close()
-		if err != nil {
-		}
-	}()
-
-	_, err = port.Write(command)
+// sendRequest writes the command to the transport and reads and classifies the answer
+func sendRequest(t Transport, command []byte, timing Timing) ([]byte, Response, error) {
+	_, err := t.Write(command)
 	if err != nil {
-		return nil, err
+		return nil, ResponseNone, err
 	}
-	buf := make([]byte, rtuMaxSize)
-	//TODO: port.Read or io.ReadAll ?
-	//_, err = port.Read(buf)
-	buf, err = io.ReadAll(port)
-	if err != nil {
-		return nil, err
-	}
-	//_, err = io.ReadFull(port, buf)
-	//io.ReadAtLeast(port, data[:], rtuMaxSize)
-	if err != nil {
-		return buf, err
-	}
-	return buf, err
+	return readResponse(t, timing)
 }
 
-// sendSingle SendMessage Command and request will be 1 byte ie. ping if device exist on serial port and device address
-// If the port is in use by another application, it will retry until the port becomes available
-// or until the timeout is reached
-func sendSingle(serialPort string, command []byte) ([1]byte, error) {
-
-	config := serial.Config{
-		Name:        serialPort,
-		Baud:        2400,
-		Size:        8,
-		StopBits:    1,
-		Parity:      serial.ParityEven,
-		ReadTimeout: 150 * time.Millisecond,
-		//Timeout:  100 * time.Millisecond,
-	}
-
-	port, err := serial.OpenPort(&config)
+// sendProbe writes the command and classifies the answer like sendRequest, but
+// tells several ACKs apart from one
+func sendProbe(t Transport, command []byte, timing Timing) ([]byte, Response, error) {
+	_, err := t.Write(command)
 	if err != nil {
-		return [1]byte{0}, err
+		return nil, ResponseNone, err
 	}
+	return readProbeResponse(t, timing)
+}
 
-	defer func() {
-		err := port.Close()
-		if err != nil {
-		}
-	}()
+// probeAddress sends SND_NKE to the address and classifies the answer
+func probeAddress(t Transport, deviceAddress uint, timing Timing) (Response, error) {
+	_, response, err := sendProbe(t, COMMAND_SND_NKE(deviceAddress), timing)
+	return response, err
+}
 
-	_, err = port.Write(command)
+// sendUserData sends SND_UD and waits for the ACK of the slave
+func sendUserData(t Transport, deviceAddress uint, ci CIField, data []byte, timing Timing) error {
+	command, err := COMMAND_SND_UD(deviceAddress, ci, data)
 	if err != nil {
-		return [1]byte{0}, err
+		return err
 	}
-
-	buf, err := io.ReadAll(port)
+	_, response, err := sendRequest(t, command, timing)
 	if err != nil {
-		return [1]byte{}, err
+		return err
 	}
-	// Convert buf to [1]byte
-	var data [1]byte
-	copy(data[:], buf[:1])
-	return data, nil
+	if response != ResponseAck {
+		return ErrNoAck
+	}
+	return nil
 }
 
-// pingAddress Ping if device exist on serial port and address
-// If the port is in use by another application, it will retry until the port becomes available
-// or until the timeout is reached
-func pingAddress(serialPort string, deviceAddress uint) (bool, error) {
-
-	answer, err := sendSingle(serialPort, COMMAND_SND_NKE(deviceAddress))
+// ErrNoAck is returned when the slave does not confirm a command with 0xE5
+var ErrNoAck = errors.New("slave did not acknowledge the command")
+
+// maxTelegrams limits a multi-telegram readout, so a slave that always signals
+// "more records follow" can not block the bus
+const maxTelegrams = 16
+
+// ErrTooManyTelegrams is returned when a readout does not end within maxTelegrams telegrams
+var ErrTooManyTelegrams = errors.New("too many telegrams in readout")
+
+// readDeviceState requests class 2 data, the first telegram with the frame count bit
+// fcb. While a telegram ends with DIF 0x1F "more records follow", the next telegram
+// is requested with toggled FCB. All telegrams are merged into one result without
+// the 0x1F records. It returns the FCB for the next request to the slave, a
+// telegram that was not received is requested again with the same bit.
+func readDeviceState(t Transport, deviceAddress uint, fcb bool, timing Timing) (LFrameParsed, bool, error) {
+	var result LFrameParsed
+	for i := 0; i < maxTelegrams; i++ {
+		command := COMMAND_REQ_UD2(deviceAddress)
+		if fcb {
+			command = COMMAND_REQ_UD2_1(deviceAddress)
+		}
+		telegram, err := readTelegram(t, command, timing)
+		if err != nil {
+			return result, fcb, err
+		}
+		fcb = !fcb
+		telegram.dropMoreRecordsFollow()
 
-	if err == nil {
-		alive := answer[0] == 0xE5
-		return alive, nil
+		if i == 0 {
+			result = telegram
+		} else {
+			result.append(telegram)
+		}
+		if !telegram.Telegrams[0].MoreRecordsFollow {
+			return result, fcb, nil
+		}
 	}
-
-	return false, err
+	return result, fcb, fmt.Errorf("%w: more than %d", ErrTooManyTelegrams, maxTelegrams)
 }
 
-func readDeviceState(serialPort string, deviceAddress uint) (LFrameParsed, error) {
-	rawData, err := sendDataRequest(serialPort, deviceAddress, COMMAND_REQ_UD2(deviceAddress))
+// readTelegram sends the request and parses the RSP_UD answer
+func readTelegram(t Transport, command []byte, timing Timing) (LFrameParsed, error) {
+	rawData, response, err := sendRequest(t, command, timing)
 	if err != nil {
 		return LFrameParsed{}, err
 	}
+	if response == ResponseNone {
+		return LFrameParsed{}, ErrNoResponse
+	}
 
 	frame := NewLFrame(rawData)
+	if err := verifyTelegram(&frame); err != nil {
+		return LFrameParsed{}, err
+	}
 
 	return frame.parse()
 }
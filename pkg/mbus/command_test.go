@@ -0,0 +1,234 @@
+package mbus
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+// testRspUd is a valid RSP_UD telegram of slave address 1 with one BCD volume record
+var testRspUd = []byte{
+	0x68, 0x15, 0x15, 0x68,
+	0x08, 0x01, 0x72,
+	0x78, 0x56, 0x34, 0x12, 0x24, 0x40, 0x01, 0x07, 0x55, 0x00, 0x00, 0x00,
+	0x0C, 0x13, 0x27, 0x04, 0x85, 0x02,
+	0x21, 0x16,
+}
+
+// fakeSlave answers every request read from conn with the reply returned by answer.
+// A nil reply means the slave stays silent.
+func fakeSlave(t *testing.T, conn net.Conn, answer func(request []byte) []byte) {
+	t.Helper()
+	go func() {
+		defer conn.Close()
+		buf := make([]byte, 256)
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				return
+			}
+			reply := answer(append([]byte{}, buf[:n]...))
+			if reply == nil {
+				continue
+			}
+			if _, err := conn.Write(reply); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+func TestPingWith(t *testing.T) {
+	tests := []struct {
+		name          string
+		address       int
+		want          bool
+		wantCollision bool
+	}{
+		{name: "Device answers with ACK", address: 1, want: true},
+		{name: "No device at address", address: 2, want: false},
+		{name: "Two devices at address", address: 3, want: false, wantCollision: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, slave := NewPipeTransport()
+			defer tr.Close()
+			fakeSlave(t, slave, func(request []byte) []byte {
+				switch request[2] {
+				case 0x01:
+					return []byte{FRAME_ACK_START}
+				case 0x03:
+					return []byte{FRAME_ACK_START, FRAME_ACK_START}
+				}
+				return nil
+			})
+
+			got := PingWith(tr, tt.address)
+			if got.State != tt.want {
+				t.Errorf("PingWith() state = %v, want %v (error: %s)", got.State, tt.want, got.Error)
+			}
+			if got.Collision != tt.wantCollision {
+				t.Errorf("PingWith() collision = %v, want %v", got.Collision, tt.wantCollision)
+			}
+		})
+	}
+}
+
+func TestReadWith(t *testing.T) {
+	tr, slave := NewPipeTransport()
+	defer tr.Close()
+
+	var request []byte
+	fakeSlave(t, slave, func(r []byte) []byte {
+		request = r
+		return testRspUd
+	})
+
+	ds := ReadWith(tr, 1)
+	if ds.Error != "" {
+		t.Fatalf("ReadWith() error = %s", ds.Error)
+	}
+
+	wantRequest := COMMAND_REQ_UD2(1)
+	if string(request) != string(wantRequest) {
+		t.Errorf("ReadWith() request = % X, want % X", request, wantRequest)
+	}
+	if ds.Data.IdentificationNumber != "12345678" {
+		t.Errorf("ReadWith() IdentificationNumber = %v, want %v", ds.Data.IdentificationNumber, "12345678")
+	}
+	if len(ds.Data.Records) != 1 {
+		t.Fatalf("ReadWith() got %d records, want 1", len(ds.Data.Records))
+	}
+	if ds.Data.Records[0].Name != "Volume" {
+		t.Errorf("ReadWith() record name = %v, want %v", ds.Data.Records[0].Name, "Volume")
+	}
+}
+
+func TestReadWith_RejectsCorruptTelegram(t *testing.T) {
+	corrupt := append([]byte{}, testRspUd...)
+	corrupt[20]++ // flip a data byte, the checksum no longer matches
+
+	tr, slave := NewPipeTransport()
+	defer tr.Close()
+	fakeSlave(t, slave, func(request []byte) []byte {
+		return corrupt
+	})
+
+	ds := ReadWith(tr, 1)
+	if ds.Error == "" {
+		t.Fatalf("ReadWith() error = \"\", want checksum error")
+	}
+	if len(ds.Data.Records) != 0 {
+		t.Errorf("ReadWith() got %d records from a corrupt telegram, want 0", len(ds.Data.Records))
+	}
+}
+
+func TestReadWith_RejectsControlFrame(t *testing.T) {
+	// A valid control frame has no fixed data header to parse
+	control := []byte{0x68, 0x03, 0x03, 0x68, 0x08, 0x01, 0x72, 0x7B, 0x16}
+
+	tr, slave := NewPipeTransport()
+	fakeSlave(t, slave, func(request []byte) []byte {
+		return control
+	})
+
+	ds := ReadWith(tr, 1)
+	if !strings.Contains(ds.Error, ErrFrameLength.Error()) {
+		t.Errorf("ReadWith() error = %q, want %q", ds.Error, ErrFrameLength)
+	}
+
+	// On a bus the answer must not take down the worker
+	bus := NewBus(tr)
+	defer bus.Close()
+	if _, err := bus.ReadUD2(1); !errors.Is(err, ErrFrameLength) {
+		t.Errorf("Bus.ReadUD2() error = %v, want %v", err, ErrFrameLength)
+	}
+	if _, err := bus.ReadUD2(1); !errors.Is(err, ErrFrameLength) {
+		t.Errorf("Bus.ReadUD2() after a control frame error = %v, want %v", err, ErrFrameLength)
+	}
+}
+
+// testTelegram builds an RSP_UD telegram of slave address 1 with the header of
+// testRspUd, the access number and the records
+func testTelegram(t *testing.T, accessNumber byte, records ...byte) []byte {
+	t.Helper()
+	header := append([]byte{}, testRspUd[7:19]...)
+	header[8] = accessNumber
+	frame, err := EncodeLFrame(CFIELD_RSP_UD_a, NewAField(1), CiFieldVariable72, append(header, records...))
+	if err != nil {
+		t.Fatalf("EncodeLFrame() error = %v", err)
+	}
+	return frame.Bytes()
+}
+
+func TestReadWith_MultiTelegram(t *testing.T) {
+	first := testTelegram(t, 0x55, 0x0C, 0x13, 0x27, 0x04, 0x85, 0x02, 0x1F)
+	second := testTelegram(t, 0x56, 0x0C, 0x13, 0x11, 0x00, 0x00, 0x00)
+
+	tr, slave := NewPipeTransport()
+	defer tr.Close()
+
+	var requests [][]byte
+	fakeSlave(t, slave, func(r []byte) []byte {
+		requests = append(requests, r)
+		if r[1] == CFIELD_REQ_UD2_1.getByte() {
+			return second
+		}
+		return first
+	})
+
+	ds := ReadWith(tr, 1)
+	if ds.Error != "" {
+		t.Fatalf("ReadWith() error = %s", ds.Error)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("ReadWith() sent %d requests, want 2", len(requests))
+	}
+	if string(requests[1]) != string(COMMAND_REQ_UD2_1(1)) {
+		t.Errorf("second request = % X, want % X", requests[1], COMMAND_REQ_UD2_1(1))
+	}
+
+	// Volume of both telegrams without the more records follow marker
+	if len(ds.Data.Records) != 2 {
+		t.Fatalf("ReadWith() got %d records, want 2", len(ds.Data.Records))
+	}
+	for i, record := range ds.Data.Records {
+		if record.DIF == 0x1F {
+			t.Errorf("ReadWith() record %d is the more records follow marker", i)
+		}
+	}
+	if ds.Data.Records[1].Value != "0.011000" {
+		t.Errorf("ReadWith() last record value = %v, want 0.011000", ds.Data.Records[1].Value)
+	}
+
+	want := []TelegramInfo{
+		{AccessNumber: 0x55, FirstRecord: 0, RecordCount: 1, MoreRecordsFollow: true},
+		{AccessNumber: 0x56, FirstRecord: 1, RecordCount: 1},
+	}
+	if len(ds.Data.Telegrams) != len(want) {
+		t.Fatalf("ReadWith() got %d telegrams, want %d", len(ds.Data.Telegrams), len(want))
+	}
+	for i := range want {
+		if ds.Data.Telegrams[i] != want[i] {
+			t.Errorf("telegram %d = %+v, want %+v", i, ds.Data.Telegrams[i], want[i])
+		}
+	}
+}
+
+func TestReadWith_TooManyTelegrams(t *testing.T) {
+	endless := testTelegram(t, 0x55, 0x1F)
+
+	tr, slave := NewPipeTransport()
+	defer tr.Close()
+	fakeSlave(t, slave, func(r []byte) []byte {
+		return endless
+	})
+
+	ds := ReadWith(tr, 1)
+	if !strings.Contains(ds.Error, ErrTooManyTelegrams.Error()) {
+		t.Errorf("ReadWith() error = %q, want %q", ds.Error, ErrTooManyTelegrams)
+	}
+}
@@ -0,0 +1,324 @@
+package mbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CommissionPlan tells which primary address each meter gets
+type CommissionPlan struct {
+	// Addresses maps the identification number (8 digits) or the full secondary
+	// address (16 digits) of a meter to its primary address
+	Addresses map[string]int `yaml:"addresses" json:"addresses"`
+	// First is the first primary address for meters that are not in Addresses,
+	// they get the free addresses in sequence. Default 1.
+	First int `yaml:"first" json:"first"`
+	// DryRun only searches and reads the meters, no address is written
+	DryRun bool `yaml:"dry_run" json:"dry_run"`
+}
+
+// ReadCommissionPlan reads a YAML commissioning plan
+func ReadCommissionPlan(r io.Reader) (CommissionPlan, error) {
+	var plan CommissionPlan
+	if err := yaml.NewDecoder(r).Decode(&plan); err != nil && err != io.EOF {
+		return plan, err
+	}
+	return plan, nil
+}
+
+// Inventory is the result of a commissioning run
+type Inventory struct {
+	Port      string           `yaml:"port" json:"port"`
+	Timestamp time.Time        `yaml:"timestamp" json:"timestamp"`
+	Meters    []InventoryEntry `yaml:"meters" json:"meters"`
+	// Collisions are secondary address masks of meters that differ only in the
+	// manufacturer, they must be commissioned by hand
+	Collisions []string `yaml:"collisions,omitempty" json:"collisions,omitempty"`
+}
+
+// InventoryEntry is one meter of the inventory
+type InventoryEntry struct {
+	SecondaryAddress     string `yaml:"secondary_address" json:"secondary_address"`
+	IdentificationNumber string `yaml:"identification_number" json:"identification_number"`
+	Manufacturer         string `yaml:"manufacturer" json:"manufacturer"`
+	Version              uint   `yaml:"version" json:"version"`
+	Medium               string `yaml:"medium" json:"medium"`
+	// PreviousAddress is the primary address before commissioning
+	PreviousAddress int `yaml:"previous_address" json:"previous_address"`
+	// PrimaryAddress is the primary address after commissioning
+	PrimaryAddress int `yaml:"primary_address" json:"primary_address"`
+	// Verified is true when the meter answered at PrimaryAddress
+	Verified bool   `yaml:"verified" json:"verified"`
+	Error    string `yaml:"error,omitempty" json:"error,omitempty"`
+}
+
+// WriteYAML writes the inventory as YAML
+func (inv *Inventory) WriteYAML(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(inv); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// Commission searches all meters on the port by secondary address, reads their
+// header and assigns primary addresses according to the plan.
+func Commission(port string, plan CommissionPlan) (Inventory, error) {
+	return CommissionContext(context.Background(), port, plan)
+}
+
+// CommissionWith commissions the meters using an already open transport. See Commission.
+func CommissionWith(t Transport, plan CommissionPlan) (Inventory, error) {
+	return CommissionWithContext(context.Background(), t, plan)
+}
+
+// CommissionContext is Commission that stops the search and the remaining address
+// changes when the context is done and closes the port immediately.
+func CommissionContext(ctx context.Context, port string, plan CommissionPlan) (Inventory, error) {
+	t, err := openTransportWaitContext(ctx, port)
+	if err != nil {
+		return Inventory{Port: port, Timestamp: time.Now()}, err
+	}
+	t, release := ownTransport(ctx, t)
+	defer release()
+
+	inv, err := CommissionWithContext(ctx, t, plan)
+	inv.Port = port
+	return inv, err
+}
+
+// CommissionWithContext is CommissionWith that stops the search and the remaining
+// address changes when the context is done; the transport stays open.
+func CommissionWithContext(ctx context.Context, t Transport, plan CommissionPlan) (Inventory, error) {
+	ct, release := withContext(ctx, t)
+	defer release()
+
+	return commission(ct, plan, transportTiming(t))
+}
+
+// ErrInvalidPlan is returned for a commissioning plan with an invalid or duplicate address
+var ErrInvalidPlan = errors.New("invalid commissioning plan")
+
+// validate checks that every planned address is a primary address and used only once
+func (plan CommissionPlan) validate() error {
+	meters := make(map[int]string, len(plan.Addresses))
+	for meter, address := range plan.Addresses {
+		if address < 0 || address > 250 {
+			return fmt.Errorf("%w: %s: %w: %d", ErrInvalidPlan, meter, ErrInvalidSlaveAddress, address)
+		}
+		if other, ok := meters[address]; ok {
+			return fmt.Errorf("%w: %s and %s both get address %d", ErrInvalidPlan, other, meter, address)
+		}
+		meters[address] = meter
+	}
+	return nil
+}
+
+// commission runs the secondary search, reads every meter and writes the primary
+// addresses. A meter that is not in the plan keeps its address if no other meter
+// has it and the plan does not reserve it. Other meters get the next address that
+// the plan does not reserve, no meter had before and where no slave answers.
+// Errors of single meters are recorded in the inventory, the run goes on with the
+// next meter.
+func commission(t Transport, plan CommissionPlan, timing Timing) (Inventory, error) {
+	inv := Inventory{Timestamp: time.Now()}
+	if err := plan.validate(); err != nil {
+		return inv, err
+	}
+
+	search, err := searchSecondary(t, timing)
+	if err != nil {
+		return inv, err
+	}
+	for _, mask := range search.Collisions {
+		inv.Collisions = append(inv.Collisions, mask.String())
+	}
+
+	used := make(map[int]bool)
+	reserved := make(map[int]bool)
+	for _, address := range plan.Addresses {
+		used[address] = true
+		reserved[address] = true
+	}
+
+	// meters counts the meters at each primary address
+	meters := make(map[int]int)
+	for _, address := range search.Found {
+		entry := InventoryEntry{SecondaryAddress: address.String(), PreviousAddress: -1, PrimaryAddress: -1}
+		data, err := readSecondaryDeviceState(t, address, timing)
+		if err != nil {
+			entry.Error = err.Error()
+			inv.Meters = append(inv.Meters, entry)
+			continue
+		}
+		entry.IdentificationNumber = data.IdentificationNumber
+		entry.Manufacturer = data.Manufacturer
+		entry.Version = data.Version
+		entry.Medium = data.Medium
+		entry.PreviousAddress = int(data.Address)
+		inv.Meters = append(inv.Meters, entry)
+		used[entry.PreviousAddress] = true
+		meters[entry.PreviousAddress]++
+	}
+
+	var unplanned []*InventoryEntry
+	for i := range inv.Meters {
+		entry := &inv.Meters[i]
+		if entry.Error != "" {
+			continue
+		}
+		target, planned := plan.Addresses[entry.SecondaryAddress]
+		if !planned {
+			target, planned = plan.Addresses[entry.IdentificationNumber]
+		}
+		switch {
+		case planned:
+			entry.PrimaryAddress = target
+		case entry.PreviousAddress >= 1 && entry.PreviousAddress <= 250 &&
+			meters[entry.PreviousAddress] == 1 && !reserved[entry.PreviousAddress]:
+			entry.PrimaryAddress = entry.PreviousAddress
+		default:
+			unplanned = append(unplanned, entry)
+		}
+	}
+
+	next := plan.First
+	if next < 1 {
+		next = 1
+	}
+	for _, entry := range unplanned {
+		target, err := nextFreeAddress(t, next, used, timing)
+		if err != nil {
+			entry.Error = err.Error()
+			continue
+		}
+		used[target] = true
+		entry.PrimaryAddress = target
+		next = target + 1
+	}
+
+	if plan.DryRun {
+		return inv, nil
+	}
+	assignAddresses(t, inv.Meters, used, timing)
+	return inv, nil
+}
+
+// assignAddresses writes the primary addresses of the inventory. A meter whose address
+// is still held by another meter waits until that one has moved. If the meters block
+// each other in a cycle, one of them moves to a free address first.
+func assignAddresses(t Transport, entries []InventoryEntry, used map[int]bool, timing Timing) {
+	// current holds the meters that still have to move by their primary address
+	current := make(map[int][]*InventoryEntry)
+	var pending []*InventoryEntry
+	for i := range entries {
+		entry := &entries[i]
+		switch {
+		case entry.Error != "":
+		case entry.PrimaryAddress == entry.PreviousAddress:
+			verifyAddress(t, entry, timing)
+		default:
+			current[entry.PreviousAddress] = append(current[entry.PreviousAddress], entry)
+			pending = append(pending, entry)
+		}
+	}
+
+	for len(pending) > 0 {
+		var blocked []*InventoryEntry
+		for _, entry := range pending {
+			if holdsAddress(current[entry.PrimaryAddress], entry) {
+				blocked = append(blocked, entry)
+				continue
+			}
+			moveMeter(t, entry, entry.PrimaryAddress, current, timing)
+		}
+		if len(blocked) == len(pending) {
+			entry := blocked[0]
+			address, err := nextFreeAddress(t, 1, used, timing)
+			if err != nil {
+				entry.Error = err.Error()
+				releaseAddress(current, entry)
+				blocked = blocked[1:]
+			} else {
+				used[address] = true
+				moveMeter(t, entry, address, current, timing)
+			}
+		}
+		pending = blocked
+	}
+}
+
+// moveMeter writes the address to the meter. Once it reached its primary address, or
+// failed, it no longer holds an address for the other meters.
+func moveMeter(t Transport, entry *InventoryEntry, address int, current map[int][]*InventoryEntry, timing Timing) {
+	releaseAddress(current, entry)
+	secondary, _ := ParseSecondaryAddress(entry.SecondaryAddress)
+	if _, err := setPrimaryAddressSecondary(t, secondary, uint(address), timing); err != nil {
+		entry.Error = err.Error()
+		return
+	}
+	if address != entry.PrimaryAddress {
+		current[address] = append(current[address], entry)
+		return
+	}
+	entry.Verified = true
+}
+
+// holdsAddress returns true if another meter than entry is among the holders
+func holdsAddress(holders []*InventoryEntry, entry *InventoryEntry) bool {
+	for _, holder := range holders {
+		if holder != entry {
+			return true
+		}
+	}
+	return false
+}
+
+// releaseAddress removes the meter from the holders of its current address
+func releaseAddress(current map[int][]*InventoryEntry, entry *InventoryEntry) {
+	for address, holders := range current {
+		for i, holder := range holders {
+			if holder == entry {
+				current[address] = append(holders[:i:i], holders[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// verifyAddress checks that the meter that keeps its address answers there
+func verifyAddress(t Transport, entry *InventoryEntry, timing Timing) {
+	response, err := probeAddress(t, uint(entry.PrimaryAddress), timing)
+	switch {
+	case err != nil:
+		entry.Error = err.Error()
+	case response != ResponseAck:
+		entry.Error = fmt.Errorf("%w: %d", ErrNoResponse, entry.PrimaryAddress).Error()
+	default:
+		entry.Verified = true
+	}
+}
+
+// nextFreeAddress returns the first address from start that is not used and where no slave answers
+func nextFreeAddress(t Transport, start int, used map[int]bool, timing Timing) (int, error) {
+	for address := start; address <= 250; address++ {
+		if used[address] {
+			continue
+		}
+		response, err := probeAddress(t, uint(address), timing)
+		if response == ResponseNone {
+			if err != nil {
+				return 0, err
+			}
+			return address, nil
+		}
+		used[address] = true
+	}
+	return 0, fmt.Errorf("%w: no free primary address from %d", ErrInvalidSlaveAddress, start)
+}
@@ -0,0 +1,252 @@
+package mbus
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeMeter is a slave of fakeMeters with its secondary address and primary address
+type fakeMeter struct {
+	secondary string
+	address   byte
+	selected  bool
+	telegram  []byte
+}
+
+// fakeMeters simulates a segment of meters that answer SND_NKE and REQ_UD2 at their
+// primary address, can be selected by secondary address and accept a new primary address.
+func fakeMeters(t *testing.T, conn net.Conn, meters []*fakeMeter) *sync.Mutex {
+	var mu sync.Mutex
+	for _, m := range meters {
+		m.telegram = testSlave(t, m.secondary)
+	}
+	fakeSlave(t, conn, func(request []byte) []byte {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var addressed []*fakeMeter
+		a := request[2]
+		if request[0] == FRAME_LONG_START {
+			a = request[5]
+		}
+		for _, m := range meters {
+			if (a == 253 && m.selected) || (a != 253 && a == m.address) {
+				addressed = append(addressed, m)
+			}
+		}
+
+		switch {
+		case len(request) == 17 && a == 253 && request[6] == CiFieldSelectionOfSlaves:
+			addressed = nil
+			for _, m := range meters {
+				m.selected = secondaryMatches(request[7:15], m.telegram[7:15])
+				if m.selected {
+					addressed = append(addressed, m)
+				}
+			}
+			if len(addressed) > 0 {
+				return []byte{FRAME_ACK_START}
+			}
+			return nil
+		case len(addressed) == 0:
+			return nil
+		case len(addressed) > 1:
+			return []byte{FRAME_ACK_START, FRAME_ACK_START}
+		case len(request) == 5 && request[1] == CFIELD_SND_NKE.getByte():
+			return []byte{FRAME_ACK_START}
+		case len(request) == 5:
+			telegram := append([]byte{}, addressed[0].telegram...)
+			telegram[5] = addressed[0].address
+			telegram[len(telegram)-2] = checksum(telegram[4 : len(telegram)-2])
+			return telegram
+		case len(request) == 12 && CIField(request[6]) == CiFieldDataSend && request[7] == 0x01 && request[8] == 0x7A:
+			addressed[0].address = request[9]
+			return []byte{FRAME_ACK_START}
+		}
+		return nil
+	})
+	return &mu
+}
+
+func TestCommission(t *testing.T) {
+	meters := []*fakeMeter{
+		{secondary: "1234567840240107", address: 0},
+		{secondary: "8765432140240102", address: 0},
+		{secondary: "5555555540240107", address: 1},
+		{secondary: "9999999940240107", address: 3},
+	}
+
+	tr, conn := NewPipeTransport()
+	defer tr.Close()
+	mu := fakeMeters(t, conn, meters)
+
+	plan := CommissionPlan{
+		Addresses: map[string]int{"87654321": 10},
+		First:     1,
+	}
+	timing := Timing{Response: 10 * time.Millisecond, InterCharacter: 5 * time.Millisecond}
+	inv, err := commission(tr, plan, timing)
+	if err != nil {
+		t.Fatalf("commission() error = %v", err)
+	}
+
+	// Meters at a unique address keep it, the others get the free addresses from 1 on
+	want := map[string]int{
+		"1234567840240107": 2,
+		"5555555540240107": 1,
+		"8765432140240102": 10,
+		"9999999940240107": 3,
+	}
+	if len(inv.Meters) != len(want) {
+		t.Fatalf("commission() got %d meters, want %d", len(inv.Meters), len(want))
+	}
+	for _, entry := range inv.Meters {
+		if entry.Error != "" {
+			t.Errorf("%s: error = %s", entry.SecondaryAddress, entry.Error)
+		}
+		if entry.PrimaryAddress != want[entry.SecondaryAddress] {
+			t.Errorf("%s: primary address = %d, want %d", entry.SecondaryAddress, entry.PrimaryAddress, want[entry.SecondaryAddress])
+		}
+		if !entry.Verified {
+			t.Errorf("%s: not verified", entry.SecondaryAddress)
+		}
+		if entry.Manufacturer != "PAD" {
+			t.Errorf("%s: manufacturer = %s, want PAD", entry.SecondaryAddress, entry.Manufacturer)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, m := range meters {
+		if int(m.address) != want[m.secondary] {
+			t.Errorf("meter %s has address %d, want %d", m.secondary, m.address, want[m.secondary])
+		}
+	}
+}
+
+func TestCommission_Swap(t *testing.T) {
+	meters := []*fakeMeter{
+		{secondary: "1234567840240107", address: 5},
+		{secondary: "8765432140240102", address: 6},
+		{secondary: "5555555540240107", address: 5},
+		{secondary: "9999999940240107", address: 6},
+	}
+
+	tr, conn := NewPipeTransport()
+	defer tr.Close()
+	mu := fakeMeters(t, conn, meters)
+
+	// 1234... and 8765... swap their addresses, the others share them and move away
+	plan := CommissionPlan{
+		Addresses: map[string]int{"12345678": 6, "87654321": 5},
+		First:     20,
+	}
+	timing := Timing{Response: 10 * time.Millisecond, InterCharacter: 5 * time.Millisecond}
+	inv, err := commission(tr, plan, timing)
+	if err != nil {
+		t.Fatalf("commission() error = %v", err)
+	}
+
+	want := map[string]int{
+		"1234567840240107": 6,
+		"5555555540240107": 20,
+		"8765432140240102": 5,
+		"9999999940240107": 21,
+	}
+	for _, entry := range inv.Meters {
+		if entry.Error != "" || !entry.Verified {
+			t.Errorf("%s: error = %s, verified = %v", entry.SecondaryAddress, entry.Error, entry.Verified)
+		}
+		if entry.PrimaryAddress != want[entry.SecondaryAddress] {
+			t.Errorf("%s: primary address = %d, want %d", entry.SecondaryAddress, entry.PrimaryAddress, want[entry.SecondaryAddress])
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, m := range meters {
+		if int(m.address) != want[m.secondary] {
+			t.Errorf("meter %s has address %d, want %d", m.secondary, m.address, want[m.secondary])
+		}
+	}
+}
+
+func TestCommission_InvalidPlan(t *testing.T) {
+	tests := []struct {
+		name    string
+		plan    CommissionPlan
+		wantErr error
+	}{
+		{name: "Address above 250", plan: CommissionPlan{Addresses: map[string]int{"12345678": 251}}, wantErr: ErrInvalidSlaveAddress},
+		{name: "Duplicate address", plan: CommissionPlan{Addresses: map[string]int{"12345678": 5, "87654321": 5}}, wantErr: ErrInvalidPlan},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, conn := NewPipeTransport()
+			defer tr.Close()
+			fakeMeters(t, conn, nil)
+
+			timing := Timing{Response: 10 * time.Millisecond, InterCharacter: 5 * time.Millisecond}
+			if _, err := commission(tr, tt.plan, timing); !errors.Is(err, tt.wantErr) {
+				t.Errorf("commission() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCommission_DryRun(t *testing.T) {
+	meters := []*fakeMeter{{secondary: "1234567840240107", address: 0}}
+
+	tr, conn := NewPipeTransport()
+	defer tr.Close()
+	mu := fakeMeters(t, conn, meters)
+
+	timing := Timing{Response: 10 * time.Millisecond, InterCharacter: 5 * time.Millisecond}
+	inv, err := commission(tr, CommissionPlan{DryRun: true}, timing)
+	if err != nil {
+		t.Fatalf("commission() error = %v", err)
+	}
+	if len(inv.Meters) != 1 || inv.Meters[0].PrimaryAddress != 1 || inv.Meters[0].Verified {
+		t.Errorf("commission() = %+v, want planned address 1 and not verified", inv.Meters)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if meters[0].address != 0 {
+		t.Errorf("dry run changed the address to %d", meters[0].address)
+	}
+}
+
+func TestInventory_WriteYAML(t *testing.T) {
+	inv := Inventory{
+		Port: "/dev/ttyUSB0",
+		Meters: []InventoryEntry{
+			{SecondaryAddress: "1234567840240107", IdentificationNumber: "12345678", Manufacturer: "PAD", PrimaryAddress: 2, Verified: true},
+		},
+	}
+	var buf bytes.Buffer
+	if err := inv.WriteYAML(&buf); err != nil {
+		t.Fatalf("WriteYAML() error = %v", err)
+	}
+	for _, want := range []string{"port: /dev/ttyUSB0", "secondary_address: \"1234567840240107\"", "primary_address: 2", "verified: true"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("WriteYAML() = %s, want %q", buf.String(), want)
+		}
+	}
+}
+
+func TestReadCommissionPlan(t *testing.T) {
+	plan, err := ReadCommissionPlan(strings.NewReader("first: 20\naddresses:\n  \"12345678\": 5\n"))
+	if err != nil {
+		t.Fatalf("ReadCommissionPlan() error = %v", err)
+	}
+	if plan.First != 20 || plan.Addresses["12345678"] != 5 {
+		t.Errorf("ReadCommissionPlan() = %+v", plan)
+	}
+}
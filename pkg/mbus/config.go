@@ -0,0 +1,80 @@
+package mbus
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrAddressNotChanged is returned when the slave does not answer at the new primary address
+var ErrAddressNotChanged = errors.New("slave does not answer at the new primary address")
+
+// ErrWildcardAddress is returned when a command that changes a slave is sent to a
+// secondary address with wildcards, so it could change several slaves at once
+var ErrWildcardAddress = errors.New("secondary address with wildcards")
+
+// ErrAddressInUse is returned when another slave already answers at the new primary address
+var ErrAddressInUse = errors.New("primary address already in use")
+
+// setPrimaryAddress writes the new primary address with SND_UD DIF 0x01 VIF 0x7A (bus address)
+// and checks that the slave answers SND_NKE at the new address. The new address must be free,
+// setting the current address again does nothing.
+func setPrimaryAddress(t Transport, deviceAddress uint, newAddress uint, timing Timing) error {
+	if newAddress > 250 {
+		return fmt.Errorf("%w: %d", ErrInvalidSlaveAddress, newAddress)
+	}
+	if newAddress == deviceAddress {
+		return nil
+	}
+	if err := checkAddressFree(t, newAddress, timing); err != nil {
+		return err
+	}
+	return writePrimaryAddress(t, deviceAddress, newAddress, timing)
+}
+
+// setPrimaryAddressSecondary selects the slave by its secondary address and writes the new
+// primary address. It returns the previous primary address from the RSP_UD at address 253.
+func setPrimaryAddressSecondary(t Transport, address SecondaryAddress, newAddress uint, timing Timing) (uint, error) {
+	if address.HasWildcard() {
+		return 0, fmt.Errorf("%w: %s", ErrWildcardAddress, address)
+	}
+	if newAddress > 250 {
+		return 0, fmt.Errorf("%w: %d", ErrInvalidSlaveAddress, newAddress)
+	}
+	if err := checkAddressFree(t, newAddress, timing); err != nil {
+		return 0, err
+	}
+	if err := selectSecondary(t, address, timing); err != nil {
+		return 0, err
+	}
+	telegram, err := readTelegram(t, COMMAND_REQ_UD2(uint(AFieldNetworkLayerAddress)), timing)
+	if err != nil {
+		return 0, err
+	}
+	return uint(telegram.Address), writePrimaryAddress(t, uint(AFieldNetworkLayerAddress), newAddress, timing)
+}
+
+// checkAddressFree sends SND_NKE to the address, any answer means a slave uses it
+func checkAddressFree(t Transport, address uint, timing Timing) error {
+	response, err := probeAddress(t, address, timing)
+	if response != ResponseNone {
+		return fmt.Errorf("%w: %d", ErrAddressInUse, address)
+	}
+	return err
+}
+
+// writePrimaryAddress sends the new address and checks that the slave answers there
+func writePrimaryAddress(t Transport, deviceAddress uint, newAddress uint, timing Timing) error {
+	data := []byte{0x01, 0x7A, byte(newAddress)}
+	if err := sendUserData(t, deviceAddress, CiFieldDataSend, data, timing); err != nil {
+		return err
+	}
+
+	response, err := probeAddress(t, newAddress, timing)
+	if err != nil {
+		return err
+	}
+	if response != ResponseAck {
+		return fmt.Errorf("%w: %d", ErrAddressNotChanged, newAddress)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package mbus
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+// addressSlave simulates the slave of testRspUd at primary address 1. It accepts
+// selection by secondary address, REQ_UD2 at address 253 while selected and the
+// SND_UD that writes the bus address. A readOnly slave acknowledges the SND_UD but
+// keeps its address.
+func addressSlave(t *testing.T, conn net.Conn, readOnly bool) {
+	address := byte(1)
+	selected := false
+	fakeSlave(t, conn, func(request []byte) []byte {
+		switch {
+		case len(request) == 5 && request[2] == address:
+			return []byte{FRAME_ACK_START}
+		case len(request) == 5 && request[2] == 253 && selected:
+			response := append([]byte{}, testRspUd...)
+			response[5] = address
+			response[len(response)-2] = checksum(response[4 : len(response)-2])
+			return response
+		case len(request) == 17 && request[5] == 253 && request[6] == CiFieldSelectionOfSlaves:
+			selected = secondaryMatches(request[7:15], testRspUd[7:15])
+			if selected {
+				return []byte{FRAME_ACK_START}
+			}
+		case len(request) == 12 && CIField(request[6]) == CiFieldDataSend && request[7] == 0x01 && request[8] == 0x7A:
+			if request[5] != address && !(request[5] == 253 && selected) {
+				return nil
+			}
+			if !readOnly {
+				address = request[9]
+			}
+			return []byte{FRAME_ACK_START}
+		}
+		return nil
+	})
+}
+
+func TestSetPrimaryAddressWith(t *testing.T) {
+	tests := []struct {
+		name       string
+		address    int
+		newAddress int
+		readOnly   bool
+		wantErr    error
+	}{
+		{name: "Change address", address: 1, newAddress: 7},
+		{name: "Slave keeps its address", address: 1, newAddress: 7, readOnly: true, wantErr: ErrAddressNotChanged},
+		{name: "No slave at address", address: 2, newAddress: 7, wantErr: ErrNoAck},
+		{name: "Same address", address: 1, newAddress: 1},
+		{name: "New address in use", address: 2, newAddress: 1, wantErr: ErrAddressInUse},
+		{name: "Invalid new address", address: 1, newAddress: 251, wantErr: ErrInvalidSlaveAddress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, slave := NewPipeTransport()
+			defer tr.Close()
+			addressSlave(t, slave, tt.readOnly)
+
+			err := SetPrimaryAddressWith(tr, tt.address, tt.newAddress)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("SetPrimaryAddressWith() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBus_SetPrimaryAddressSecondary(t *testing.T) {
+	tests := []struct {
+		name       string
+		address    string
+		newAddress int
+		wantErr    error
+	}{
+		{name: "Full address", address: "1234567840240107", newAddress: 9},
+		{name: "Wildcard", address: "12345678FFFFFFFF", newAddress: 9, wantErr: ErrWildcardAddress},
+		{name: "Other slave", address: "8765432140240107", newAddress: 9, wantErr: ErrNotSelected},
+		{name: "New address in use", address: "1234567840240107", newAddress: 1, wantErr: ErrAddressInUse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, slave := NewPipeTransport()
+			addressSlave(t, slave, false)
+			bus := NewBus(tr)
+			defer bus.Close()
+
+			address, err := ParseSecondaryAddress(tt.address)
+			if err != nil {
+				t.Fatalf("ParseSecondaryAddress() error = %v", err)
+			}
+			err = bus.SetPrimaryAddressSecondary(address, tt.newAddress)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SetPrimaryAddressSecondary() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if alive, err := bus.Ping(9); !alive || err != nil {
+				t.Errorf("Ping(9) = %v, %v, want true", alive, err)
+			}
+		})
+	}
+}
+
+func TestBus_SetPrimaryAddressSecondary_MovesDeviceState(t *testing.T) {
+	tr, slave := NewPipeTransport()
+	addressSlave(t, slave, false)
+	bus := NewBus(tr)
+	defer bus.Close()
+
+	// The pipe has a fixed rate, requests to a slave with its own rate fail
+	if err := bus.SetDeviceBaudRate(1, 9600); err != nil {
+		t.Fatalf("SetDeviceBaudRate() error = %v", err)
+	}
+	address, err := ParseSecondaryAddress("1234567840240107")
+	if err != nil {
+		t.Fatalf("ParseSecondaryAddress() error = %v", err)
+	}
+	if err := bus.SetPrimaryAddressSecondary(address, 9); err != nil {
+		t.Fatalf("SetPrimaryAddressSecondary() error = %v", err)
+	}
+
+	if _, err := bus.Ping(9); !errors.Is(err, ErrBaudRateFixed) {
+		t.Errorf("Ping(9) error = %v, want %v", err, ErrBaudRateFixed)
+	}
+	if _, err := bus.Ping(1); err != nil {
+		t.Errorf("Ping(1) error = %v, want nil", err)
+	}
+}
+
+func TestSetPrimaryAddressSecondaryWith(t *testing.T) {
+	tr, slave := NewPipeTransport()
+	defer tr.Close()
+	addressSlave(t, slave, false)
+
+	address, err := ParseSecondaryAddress("1234567840240107")
+	if err != nil {
+		t.Fatalf("ParseSecondaryAddress() error = %v", err)
+	}
+	if err := SetPrimaryAddressSecondaryWith(tr, address, 9); err != nil {
+		t.Fatalf("SetPrimaryAddressSecondaryWith() error = %v", err)
+	}
+	if state := PingWith(tr, 9); !state.State {
+		t.Errorf("PingWith(9) = %+v, want State true", state)
+	}
+}
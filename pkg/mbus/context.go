@@ -0,0 +1,82 @@
+package mbus
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// contextTransport interrupts a transport when the context is done. The read
+// deadline is moved to the past, so a blocked Read returns, and all following
+// calls fail with the error of the context.
+type contextTransport struct {
+	Transport
+	ctx context.Context
+
+	mu       sync.Mutex
+	canceled bool
+	stop     func() bool
+}
+
+// withContext wraps the transport for one operation. Call release when the
+// operation is done, the transport itself stays open.
+func withContext(ctx context.Context, t Transport) (*contextTransport, func()) {
+	ct := &contextTransport{Transport: t, ctx: ctx}
+	ct.stop = context.AfterFunc(ctx, ct.cancel)
+	return ct, func() { ct.stop() }
+}
+
+func (ct *contextTransport) cancel() {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	ct.canceled = true
+	_ = ct.Transport.SetReadDeadline(time.Unix(1, 0))
+}
+
+func (ct *contextTransport) Write(frame []byte) (int, error) {
+	if err := ct.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return ct.Transport.Write(frame)
+}
+
+func (ct *contextTransport) Read(p []byte) (int, error) {
+	n, err := ct.Transport.Read(p)
+	if ctxErr := ct.ctx.Err(); ctxErr != nil {
+		return n, ctxErr
+	}
+	return n, err
+}
+
+// SetReadDeadline keeps the deadline in the past once the context is done
+func (ct *contextTransport) SetReadDeadline(t time.Time) error {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	if ct.canceled {
+		return ct.ctx.Err()
+	}
+	return ct.Transport.SetReadDeadline(t)
+}
+
+// SetBaudRate forwards to the wrapped transport, so the bus can switch the rate
+func (ct *contextTransport) SetBaudRate(baud int) error {
+	setter, ok := ct.Transport.(BaudRateSetter)
+	if !ok {
+		return ErrBaudRateFixed
+	}
+	return setter.SetBaudRate(baud)
+}
+
+// ownTransport wraps a transport the operation has opened itself. The transport is
+// closed as soon as the context is done, which releases the port immediately, or
+// when release is called at the end of the operation.
+func ownTransport(ctx context.Context, t Transport) (Transport, func()) {
+	closeOnCancel := context.AfterFunc(ctx, func() { t.Close() })
+	ct, release := withContext(ctx, t)
+	return ct, func() {
+		release()
+		if closeOnCancel() {
+			t.Close()
+		}
+	}
+}
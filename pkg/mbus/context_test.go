@@ -0,0 +1,166 @@
+package mbus
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPingWithContext(t *testing.T) {
+	tr, slave := NewPipeTransport()
+	defer tr.Close()
+	// The slave never answers, without the context the ping waits for the response timeout
+	fakeSlave(t, slave, func(request []byte) []byte { return nil })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	ps := PingWithContext(ctx, tr, 1)
+	if elapsed := time.Since(start); elapsed >= TimingForBaud(defaultBaud).Response {
+		t.Errorf("PingWithContext() returned after %v, want before the response timeout", elapsed)
+	}
+	if !strings.Contains(ps.Error, context.DeadlineExceeded.Error()) {
+		t.Errorf("PingWithContext() error = %q, want %q", ps.Error, context.DeadlineExceeded)
+	}
+
+	// The transport stays usable with a new context
+	if ps := PingWithContext(context.Background(), tr, 1); ps.Error != "" {
+		t.Errorf("PingWithContext() after cancel error = %q", ps.Error)
+	}
+}
+
+func TestBus_ReadUD2Context(t *testing.T) {
+	tr, slave := NewPipeTransport()
+	answered := make(chan struct{})
+	fakeSlave(t, slave, func(request []byte) []byte {
+		if len(request) == 5 && request[2] == 1 {
+			return testRspUd
+		}
+		if len(request) == 5 && request[2] == 2 {
+			// Answers too late, after the request was canceled
+			time.Sleep(50 * time.Millisecond)
+			close(answered)
+			return []byte{FRAME_ACK_START}
+		}
+		return nil
+	})
+	bus := NewBus(tr)
+	defer bus.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := bus.ReadUD2Context(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ReadUD2Context() with canceled context error = %v, want %v", err, context.Canceled)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := bus.PingContext(ctx, 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("PingContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	<-answered
+
+	// The late ACK of address 2 is discarded and not taken as the answer of address 1
+	data, err := bus.ReadUD2(1)
+	if err != nil {
+		t.Fatalf("ReadUD2() after cancel error = %v", err)
+	}
+	if data.IdentificationNumber != "12345678" {
+		t.Errorf("ReadUD2() identification number = %s, want 12345678", data.IdentificationNumber)
+	}
+}
+
+func TestBus_DoContext(t *testing.T) {
+	tr, _ := NewPipeTransport()
+	bus := NewBus(tr)
+	defer bus.Close()
+
+	// fn does not read from the transport, so the context can not interrupt it
+	release := make(chan struct{})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := call(ctx, bus, func(t Transport) (int, error) {
+		<-release
+		return 1, nil
+	})
+	close(release)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("call() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("call() returned after %v, want when the context is done", elapsed)
+	}
+
+	// The bus takes the next request when fn has returned
+	if err := bus.SetRetryPolicy(DefaultRetryPolicy()); err != nil {
+		t.Errorf("SetRetryPolicy() after cancel error = %v", err)
+	}
+}
+
+func TestWithContext_Writes(t *testing.T) {
+	tr, slave := NewPipeTransport()
+	defer tr.Close()
+	// The slave never answers, without the context each call waits for the response timeout
+	fakeSlave(t, slave, func(request []byte) []byte { return nil })
+
+	calls := map[string]func(ctx context.Context) error{
+		"SetDateTimeWithContext": func(ctx context.Context) error {
+			return SetDateTimeWithContext(ctx, tr, 1, time.Now(), TimePointCP32)
+		},
+		"ApplicationResetWithContext": func(ctx context.Context) error {
+			return ApplicationResetWithContext(ctx, tr, 1, ResetAll)
+		},
+		"WriteRecordsWithContext": func(ctx context.Context) error {
+			return WriteRecordsWithContext(ctx, tr, 1, []RecordWrite{{VIF: 0x13, DataField: DataFieldInt32}})
+		},
+	}
+	for name, fn := range calls {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			if err := fn(ctx); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("%s() error = %v, want %v", name, err, context.DeadlineExceeded)
+			}
+			if elapsed := time.Since(start); elapsed >= TimingForBaud(defaultBaud).Response {
+				t.Errorf("%s() returned after %v, want before the response timeout", name, elapsed)
+			}
+		})
+	}
+}
+
+func TestBus_WriteContext(t *testing.T) {
+	tr, slave := NewPipeTransport()
+	fakeSlave(t, slave, func(request []byte) []byte { return nil })
+	bus := NewBus(tr)
+	defer bus.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := bus.SendUDContext(ctx, 1, CiFieldDataSend, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("SendUDContext() error = %v, want %v", err, context.Canceled)
+	}
+	if err := bus.SetPrimaryAddressContext(ctx, 1, 2); !errors.Is(err, context.Canceled) {
+		t.Errorf("SetPrimaryAddressContext() error = %v, want %v", err, context.Canceled)
+	}
+	if _, err := bus.DetectBaudRateContext(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("DetectBaudRateContext() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestOpenTransportWaitContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := openTransportWaitContext(ctx, "/dev/does-not-exist"); !errors.Is(err, context.Canceled) {
+		t.Errorf("openTransportWaitContext() error = %v, want %v", err, context.Canceled)
+	}
+	if state := ReadAlarmContext(ctx, "/dev/does-not-exist", 1); !strings.Contains(state.Error, context.Canceled.Error()) {
+		t.Errorf("ReadAlarmContext() error = %q, want %q", state.Error, context.Canceled)
+	}
+	if _, err := SearchSecondaryContext(ctx, "/dev/does-not-exist"); !errors.Is(err, context.Canceled) {
+		t.Errorf("SearchSecondaryContext() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,79 @@
+package mbus
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Telegram is a decoded RSP_UD long frame: the header of the slave and all data records.
+type Telegram struct {
+	LFrameParsed
+	CField  byte `yaml:"c_field" json:"c_field"`
+	CIField byte `yaml:"ci_field" json:"ci_field"`
+}
+
+// ErrUnsupportedCIField is returned by Decode for telegrams without a variable data structure
+var ErrUnsupportedCIField = errors.New("unsupported CI field")
+
+// Decode verifies and decodes raw telegram bytes, for example captured from a
+// gateway or a log file. No serial port is needed.
+func Decode(data []byte) (*Telegram, error) {
+	frame := NewLFrame(data)
+	if err := verifyTelegram(&frame); err != nil {
+		return nil, err
+	}
+
+	parsed, err := frame.parse()
+	if err != nil {
+		return nil, err
+	}
+	ci, _ := frame.CIField()
+	return &Telegram{
+		LFrameParsed: parsed,
+		CField:       data[4],
+		CIField:      ci,
+	}, nil
+}
+
+// verifyTelegram checks that the frame is a valid long frame with the variable data
+// structure, only then the fixed header and the records can be parsed
+func verifyTelegram(frame *LFrame) error {
+	if _, err := frame.Verify(); err != nil {
+		return err
+	}
+	// Shortest variable data telegram is the 12 byte fixed header
+	if len(frame.data) < 21 {
+		return fmt.Errorf("%w: telegram has no variable data header", ErrFrameLength)
+	}
+
+	ci, _ := frame.CIField()
+	if CIField(ci) != CiFieldVariable72 {
+		return fmt.Errorf("%w: 0x%02X", ErrUnsupportedCIField, ci)
+	}
+	return nil
+}
+
+// DecodeHex decodes a telegram written as HEX string.
+//
+// example: "68 1F 1F 68 08 02 72 ...", "0x68 0x1F 0x1F ..." or "681F1F680802 72..."
+func DecodeHex(s string) (*Telegram, error) {
+	fields := strings.Fields(s)
+	// Continuous HEX without separators
+	if len(fields) == 1 && len(strings.TrimPrefix(fields[0], "0x")) > 2 {
+		continuous := strings.TrimPrefix(fields[0], "0x")
+		if len(continuous)%2 != 0 {
+			return nil, fmt.Errorf("odd number of HEX digits in %q", continuous)
+		}
+		fields = fields[:0]
+		for i := 0; i < len(continuous); i += 2 {
+			fields = append(fields, continuous[i:i+2])
+		}
+	}
+
+	data := HexStringToBytes(strings.Join(fields, " "))
+	if len(data) != len(fields) {
+		return nil, fmt.Errorf("invalid HEX telegram %q", s)
+	}
+	return Decode(data)
+}
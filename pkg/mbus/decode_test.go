@@ -0,0 +1,92 @@
+package mbus
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testTelegramHex is the water meter example telegram of the M-Bus documentation
+const testTelegramHex = "68 1F 1F 68 08 02 72 78 56 34 12 24 40 01 07 55 00 00 00 03 13 15 31 00 DA 02 3B 13 01 8B 60 04 37 18 02 18 16"
+
+func TestDecode(t *testing.T) {
+	telegram, err := Decode(testRspUd)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if telegram.IdentificationNumber != "12345678" {
+		t.Errorf("Decode() IdentificationNumber = %v, want %v", telegram.IdentificationNumber, "12345678")
+	}
+	if telegram.CIField != byte(CiFieldVariable72) {
+		t.Errorf("Decode() CIField = 0x%02X, want 0x%02X", telegram.CIField, CiFieldVariable72)
+	}
+	if len(telegram.Records) != 1 {
+		t.Errorf("Decode() got %d records, want 1", len(telegram.Records))
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	badChecksum := append([]byte{}, testRspUd...)
+	badChecksum[len(badChecksum)-2]++
+	otherCI := append([]byte{}, testRspUd...)
+	otherCI[6] = 0x78
+	otherCI[len(otherCI)-2] = checksum(otherCI[4 : len(otherCI)-2])
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{name: "Checksum mismatch", data: badChecksum, wantErr: ErrFrameChecksum},
+		{name: "Short frame", data: []byte{0x10, 0x40, 0x01, 0x41, 0x16}, wantErr: ErrFrameStart},
+		{name: "No variable data header", data: []byte{0x68, 0x03, 0x03, 0x68, 0x08, 0x01, 0x72, 0x7B, 0x16}, wantErr: ErrFrameLength},
+		{name: "Unsupported CI field", data: otherCI, wantErr: ErrUnsupportedCIField},
+		{name: "Truncated data", data: testTelegram(t, 0x55, 0x04, 0x13, 0x01), wantErr: ErrFrameLength},
+		{name: "Truncated DIFE", data: testTelegram(t, 0x55, 0x84), wantErr: ErrFrameLength},
+		{name: "Missing VIF", data: testTelegram(t, 0x55, 0x04), wantErr: ErrFrameLength},
+		{name: "Truncated VIFE", data: testTelegram(t, 0x55, 0x04, 0x93), wantErr: ErrFrameLength},
+		{name: "Truncated variable length", data: testTelegram(t, 0x55, 0x0D, 0x78, 0x05, 0x41), wantErr: ErrFrameLength},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode(tt.data); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Decode() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecodeHex(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantRecords int
+		wantErr     bool
+	}{
+		{name: "Space separated", input: testTelegramHex, wantRecords: 3},
+		{name: "0x prefixed", input: "0x" + strings.ReplaceAll(testTelegramHex, " ", " 0x"), wantRecords: 3},
+		{name: "Continuous", input: strings.ReplaceAll(testTelegramHex, " ", ""), wantRecords: 3},
+		{name: "Line breaks", input: strings.ReplaceAll(testTelegramHex, " 72 ", "\n72\n"), wantRecords: 3},
+		{name: "Invalid HEX", input: "68 1F ZZ 68", wantErr: true},
+		{name: "Odd number of digits", input: "681F1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			telegram, err := DecodeHex(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeHex() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if telegram.Manufacturer != "PAD" {
+				t.Errorf("DecodeHex() Manufacturer = %v, want %v", telegram.Manufacturer, "PAD")
+			}
+			if len(telegram.Records) != tt.wantRecords {
+				t.Errorf("DecodeHex() got %d records, want %d", len(telegram.Records), tt.wantRecords)
+			}
+		})
+	}
+}
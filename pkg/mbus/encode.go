@@ -0,0 +1,40 @@
+package mbus
+
+import "fmt"
+
+// maxUserData is the largest payload of a long frame. The L-field is one byte
+// and counts C, A and CI too.
+const maxUserData = 255 - 3
+
+// EncodeSFrame builds the short frame 10 C A CS 16.
+func EncodeSFrame(c CField, a AField) SFrame {
+	cf := c.getByte()
+	ad := byte(a)
+	return NewSFrame([]byte{FRAME_SHORT_START, cf, ad, cf + ad, FRAME_STOP})
+}
+
+// EncodeCFrame builds the control frame 68 03 03 68 C A CI CS 16.
+func EncodeCFrame(c CField, a AField, ci CIField) CFrame {
+	cf := c.getByte()
+	ad := byte(a)
+	return NewCFrame([]byte{
+		FRAME_CONTROL_START, 3, 3, FRAME_CONTROL_START,
+		cf, ad, byte(ci), cf + ad + byte(ci),
+		FRAME_STOP,
+	})
+}
+
+// EncodeLFrame builds the long frame 68 L L 68 C A CI data CS 16 with the
+// L-field and checksum computed from the payload. It is the inverse of NewLFrame.
+func EncodeLFrame(c CField, a AField, ci CIField, data []byte) (LFrame, error) {
+	if len(data) > maxUserData {
+		return LFrame{}, fmt.Errorf("%w: %d bytes of user data, maximum is %d", ErrFrameLength, len(data), maxUserData)
+	}
+	l := byte(3 + len(data))
+	b := make([]byte, 0, len(data)+9)
+	b = append(b, FRAME_LONG_START, l, l, FRAME_LONG_START)
+	b = append(b, c.getByte(), byte(a), byte(ci))
+	b = append(b, data...)
+	b = append(b, checksum(b[4:]), FRAME_STOP)
+	return NewLFrame(b), nil
+}
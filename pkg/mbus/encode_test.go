@@ -0,0 +1,91 @@
+package mbus
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestEncodeSFrame(t *testing.T) {
+	frame := EncodeSFrame(CFIELD_SND_NKE, NewAField(1))
+	want := []byte{0x10, 0x40, 0x01, 0x41, 0x16}
+	if !bytes.Equal(frame.Bytes(), want) {
+		t.Errorf("EncodeSFrame() = % X, want % X", frame.Bytes(), want)
+	}
+	if _, err := frame.Verify(); err != nil {
+		t.Errorf("EncodeSFrame() Verify() error = %v", err)
+	}
+}
+
+func TestEncodeCFrame(t *testing.T) {
+	frame := EncodeCFrame(CFIELD_SND_UD_0, NewAField(1), CiFieldBaudrate9600)
+	want := []byte{0x68, 0x03, 0x03, 0x68, 0x53, 0x01, 0xBD, 0x11, 0x16}
+	if !bytes.Equal(frame.Bytes(), want) {
+		t.Errorf("EncodeCFrame() = % X, want % X", frame.Bytes(), want)
+	}
+	if _, err := frame.Verify(); err != nil {
+		t.Errorf("EncodeCFrame() Verify() error = %v", err)
+	}
+}
+
+func TestEncodeLFrame(t *testing.T) {
+	tests := []struct {
+		name    string
+		c       CField
+		a       AField
+		ci      CIField
+		data    []byte
+		want    []byte
+		wantErr error
+	}{
+		{
+			name: "SND_UD set primary address",
+			c:    CFIELD_SND_UD_0,
+			a:    NewAField(1),
+			ci:   CiFieldDataSend,
+			data: []byte{0x01, 0x7A, 0x02},
+			want: []byte{0x68, 0x06, 0x06, 0x68, 0x53, 0x01, 0x51, 0x01, 0x7A, 0x02, 0x22, 0x16},
+		},
+		{
+			name: "RSP_UD round trip",
+			c:    CFIELD_RSP_UD_a,
+			a:    NewAField(1),
+			ci:   CiFieldVariable72,
+			data: testRspUd[7 : len(testRspUd)-2],
+			want: testRspUd,
+		},
+		{
+			name: "No user data",
+			c:    CFIELD_SND_UD_0,
+			a:    NewAField(1),
+			ci:   CiFieldApplicationReset,
+			want: []byte{0x68, 0x03, 0x03, 0x68, 0x53, 0x01, 0x50, 0xA4, 0x16},
+		},
+		{
+			name:    "Too much user data",
+			c:       CFIELD_SND_UD_0,
+			a:       NewAField(1),
+			ci:      CiFieldDataSend,
+			data:    make([]byte, 253),
+			wantErr: ErrFrameLength,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame, err := EncodeLFrame(tt.c, tt.a, tt.ci, tt.data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("EncodeLFrame() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if !bytes.Equal(frame.Bytes(), tt.want) {
+				t.Errorf("EncodeLFrame() = % X, want % X", frame.Bytes(), tt.want)
+			}
+			if _, err := frame.Verify(); err != nil {
+				t.Errorf("EncodeLFrame() Verify() error = %v", err)
+			}
+		})
+	}
+}
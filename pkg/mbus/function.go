@@ -26,6 +26,23 @@ func DecodeManufacturerId(data []byte) string {
 	return string([]byte{byte(a1), byte(a2), byte(a3)})
 }
 
+// EncodeManufacturerId Encode 3 ASCII Characters A-Z into 2 bytes - Manufacturer ID
+func EncodeManufacturerId(code string) ([]byte, error) {
+	if len(code) != 3 {
+		return nil, fmt.Errorf("manufacturer ID %q must have 3 characters", code)
+	}
+	var i uint16
+	for _, c := range []byte(strings.ToUpper(code)) {
+		if c < 'A' || c > 'Z' {
+			return nil, fmt.Errorf("manufacturer ID %q must be letters A-Z", code)
+		}
+		i = i<<5 | uint16(c-64)
+	}
+	b := make([]byte, 2)
+	binary.LittleEndian.PutUint16(b, i)
+	return b, nil
+}
+
 // BoolToInt Convert bool to int: true -> 1, false -> 0
 func BoolToInt(b bool) int {
 	if b {
@@ -57,7 +74,10 @@ func HexStringToByte(s string) (byte, error) {
 		return 0, errors.New("String is empty")
 	}
 	sclean := strings.Replace(s, "0x", "", -1)
-	h, _ := hex.DecodeString(sclean)
+	h, err := hex.DecodeString(sclean)
+	if err != nil || len(h) == 0 {
+		return 0, fmt.Errorf("%q is not a HEX byte", s)
+	}
 	return h[0], nil
 }
 
@@ -314,6 +334,93 @@ func From32intTimePoint(b []byte) (string, error) {
 	return output, nil
 }
 
+// ErrTimePointRange is returned for a date the two digit year of a time point can not hold
+var ErrTimePointRange = errors.New("time point out of range 1980-2079")
+
+// timePointYear returns the two digit year as read by From16intTimePoint and From32intTimePoint
+func timePointYear(t time.Time) (byte, error) {
+	year := t.Year()
+	if year < 1980 || year > 2079 {
+		return 0, fmt.Errorf("%w: %d", ErrTimePointRange, year)
+	}
+	return byte(year % 100), nil
+}
+
+// EncodeTimePointCP32 encodes the wall clock of t as Type F: Compound CP32: Date and Time,
+// the inverse of From32intTimePoint. Seconds are dropped, the summer time flag is set if
+// t is in daylight saving time in its location.
+func EncodeTimePointCP32(t time.Time) ([]byte, error) {
+	year, err := timePointYear(t)
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 4)
+	b[0] = byte(t.Minute()) & 0x3f
+	b[1] = byte(t.Hour()) & 0x1f
+	if t.IsDST() {
+		b[1] |= 0x80
+	}
+	b[2] = byte(t.Day())&0x1f | (year&0x07)<<5
+	b[3] = byte(t.Month())&0x0f | (year&0x78)<<1
+	return b, nil
+}
+
+// EncodeTimePointCP48 encodes the wall clock of t as Type I: Compound CP48: Date and Time
+// with seconds and day of week, the inverse of From48intTimePoint.
+func EncodeTimePointCP48(t time.Time) ([]byte, error) {
+	year, err := timePointYear(t)
+	if err != nil {
+		return nil, err
+	}
+	// Day of week 1 = Monday ... 7 = Sunday
+	weekday := byte(t.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	b := make([]byte, 6)
+	b[0] = byte(t.Second()) & 0x3f
+	if t.IsDST() {
+		b[0] |= 0x40
+	}
+	b[1] = byte(t.Minute()) & 0x3f
+	b[2] = byte(t.Hour())&0x1f | weekday<<5
+	b[3] = byte(t.Day())&0x1f | (year&0x07)<<5
+	b[4] = byte(t.Month())&0x0f | (year&0x78)<<1
+	// b[5] week of the year 0 = not specified
+	return b, nil
+}
+
+// From48intTimePoint VIF 0x6D (date/time) with DIF data field 0x06
+// Type I: Compound CP48: Date and Time
+func From48intTimePoint(b []byte) (string, error) {
+	if len(b) != 6 {
+		return "", fmt.Errorf("From48intTimePoint: length of byte array must be 6 and not %v", len(b))
+	}
+
+	second := int(b[0] & 0x3f)
+	minute := int(b[1] & 0x3f)
+	hour := int(b[2] & 0x1f)
+	day := int(b[3] & 0x1f)
+	mon := int(b[4] & 0x0f)
+	baseYear := int(
+		((b[3] & 0xe0) >> 5) |
+			((b[4] & 0xf0) >> 1))
+
+	year := 1900 + baseYear
+	if year < 1980 {
+		year += 100
+	}
+	output := fmt.Sprintf("%04d-%02d-%02dT%02d:%02d:%02dZ",
+		year,
+		mon,
+		day,
+		hour,
+		minute,
+		second)
+
+	return output, nil
+}
+
 func From32real(b []byte, exponent float64) string {
 	data := binary.LittleEndian.Uint32(b)
 	dataF := math.Float32frombits(data)
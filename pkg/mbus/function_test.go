@@ -1,7 +1,10 @@
 package mbus
 
 import (
+	"bytes"
+	"errors"
 	"testing"
+	"time"
 )
 
 func TestBoolToInt(t *testing.T) {
@@ -66,6 +69,10 @@ func TestHexStringToByte(t *testing.T) {
 			}
 		})
 	}
+
+	if _, err := HexStringToByte("ZZ"); err == nil {
+		t.Errorf("HexStringToByte() error = nil, want error for invalid HEX")
+	}
 }
 
 func TestHexStringToBytes(t *testing.T) {
@@ -173,3 +180,82 @@ func TestFrom16intTimePoint(t *testing.T) {
 	}
 
 }
+
+func TestEncodeManufacturerId(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []byte
+		wantErr bool
+	}{
+		{"PAD", "PAD", []byte{0x24, 0x40}, false},
+		{"lower case", "pad", []byte{0x24, 0x40}, false},
+		{"too long", "PADX", nil, true},
+		{"not a letter", "P4D", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EncodeManufacturerId(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EncodeManufacturerId() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !BytesAreEqual(got, tt.want) {
+				t.Errorf("EncodeManufacturerId() = % X, want % X", got, tt.want)
+			}
+			if !tt.wantErr && DecodeManufacturerId(got) != "PAD" {
+				t.Errorf("DecodeManufacturerId() = %v, want PAD", DecodeManufacturerId(got))
+			}
+		})
+	}
+}
+
+func TestEncodeTimePointCP32(t *testing.T) {
+	tests := []struct {
+		name    string
+		time    time.Time
+		want    []byte
+		wantErr error
+	}{
+		{name: "1995", time: time.Date(1995, 3, 3, 12, 0, 0, 0, time.UTC), want: []byte{0x00, 0x0C, 0xE3, 0xB3}},
+		{name: "2012", time: time.Date(2012, 7, 10, 15, 25, 0, 0, time.UTC), want: []byte{0x19, 0x0F, 0x8A, 0x17}},
+		{name: "Seconds are dropped", time: time.Date(2014, 3, 13, 11, 11, 59, 0, time.UTC), want: []byte{0x0B, 0x0B, 0xCD, 0x13}},
+		{name: "Out of range", time: time.Date(2080, 1, 1, 0, 0, 0, 0, time.UTC), wantErr: ErrTimePointRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EncodeTimePointCP32(tt.time)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("EncodeTimePointCP32() error = %v, want %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("EncodeTimePointCP32() = % X, want % X", got, tt.want)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			decoded, _ := From32intTimePoint(got)
+			if want := tt.time.Truncate(time.Minute).Format(time.RFC3339); decoded != want {
+				t.Errorf("From32intTimePoint(EncodeTimePointCP32()) = %s, want %s", decoded, want)
+			}
+		})
+	}
+}
+
+func TestEncodeTimePointCP48(t *testing.T) {
+	dateTime := time.Date(2024, 2, 29, 23, 59, 58, 0, time.UTC)
+	got, err := EncodeTimePointCP48(dateTime)
+	if err != nil {
+		t.Fatalf("EncodeTimePointCP48() error = %v", err)
+	}
+	// Thursday is day 4 of the week
+	if got[2]>>5 != 4 {
+		t.Errorf("EncodeTimePointCP48() day of week = %d, want 4", got[2]>>5)
+	}
+	decoded, err := From48intTimePoint(got)
+	if err != nil {
+		t.Fatalf("From48intTimePoint() error = %v", err)
+	}
+	if want := dateTime.Format(time.RFC3339); decoded != want {
+		t.Errorf("From48intTimePoint(EncodeTimePointCP48()) = %s, want %s", decoded, want)
+	}
+}
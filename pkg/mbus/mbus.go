@@ -1,31 +1,534 @@
 package mbus
 
-import "time"
+import (
+	"context"
+	"fmt"
+	"time"
+)
 
 func Ping(port string, address int) PingState {
+	return PingContext(context.Background(), port, address)
+}
 
-	ps := PingState{}
+// PingWith checks if a device is alive at the given address using an already open transport.
+func PingWith(t Transport, address int) PingState {
+	return PingWithContext(context.Background(), t, address)
+}
+
+// PingContext checks if a device is alive at the given address. When the context is
+// done, waiting for the answer stops and the port is closed immediately.
+func PingContext(ctx context.Context, port string, address int) PingState {
+	t, err := OpenTransport(port)
+	if err != nil {
+		return PingState{Port: port, Address: address, Timestamp: time.Now(), Error: err.Error()}
+	}
+	t, release := ownTransport(ctx, t)
+	defer release()
+
+	ps := PingWithContext(ctx, t, address)
 	ps.Port = port
+	return ps
+}
+
+// PingWithContext checks if a device is alive using an already open transport. When the
+// context is done, waiting for the answer stops; the transport stays open.
+func PingWithContext(ctx context.Context, t Transport, address int) PingState {
+	ct, release := withContext(ctx, t)
+	defer release()
+
+	ps := PingState{}
 	ps.Address = address
 	ps.Timestamp = time.Now()
-	state, err := pingAddress(port, uint(address))
+	response, err := probeAddress(ct, uint(address), transportTiming(t))
 	if err != nil {
 		ps.Error = err.Error()
 	}
-	ps.State = state
+	ps.State = response == ResponseAck
+	ps.Collision = response == ResponseCollision
 	return ps
-
 }
 
 func Read(port string, address int) DeviceState {
-	ds := DeviceState{}
+	return ReadContext(context.Background(), port, address)
+}
+
+// ReadWith reads data from a device at the given address using an already open transport.
+func ReadWith(t Transport, address int) DeviceState {
+	return ReadWithContext(context.Background(), t, address)
+}
+
+// ReadContext reads data from a device at the given address. When the context is done,
+// waiting for the port or the answer stops and the port is closed immediately.
+func ReadContext(ctx context.Context, port string, address int) DeviceState {
+	t, err := openTransportWaitContext(ctx, port)
+	if err != nil {
+		return DeviceState{Port: port, Address: address, Timestamp: time.Now(), Error: err.Error()}
+	}
+	t, release := ownTransport(ctx, t)
+	defer release()
+
+	ds := ReadWithContext(ctx, t, address)
 	ds.Port = port
+	return ds
+}
+
+// ReadWithContext reads data from a device using an already open transport. When the
+// context is done, waiting for the answer stops; the transport stays open.
+func ReadWithContext(ctx context.Context, t Transport, address int) DeviceState {
+	ct, release := withContext(ctx, t)
+	defer release()
+
+	ds := DeviceState{}
 	ds.Address = address
 	ds.Timestamp = time.Now()
-	data, err := readDeviceState(port, uint(address))
+	data, _, err := readDeviceState(ct, uint(address), false, transportTiming(t))
 	if err != nil {
 		ds.Error = err.Error()
 	}
 	ds.Data = data
 	return ds
 }
+
+// ReadSecondary selects the device by its secondary address and reads its data at address 253.
+func ReadSecondary(port string, address SecondaryAddress) DeviceState {
+	return ReadSecondaryContext(context.Background(), port, address)
+}
+
+// ReadSecondaryWith selects the device by its secondary address and reads its data using an already open transport.
+func ReadSecondaryWith(t Transport, address SecondaryAddress) DeviceState {
+	return ReadSecondaryWithContext(context.Background(), t, address)
+}
+
+// ReadSecondaryContext is ReadSecondary that stops waiting for the port or the answer
+// when the context is done and closes the port immediately.
+func ReadSecondaryContext(ctx context.Context, port string, address SecondaryAddress) DeviceState {
+	t, err := openTransportWaitContext(ctx, port)
+	if err != nil {
+		return DeviceState{Port: port, Address: int(AFieldNetworkLayerAddress), SecondaryAddress: address.String(), Timestamp: time.Now(), Error: err.Error()}
+	}
+	t, release := ownTransport(ctx, t)
+	defer release()
+
+	ds := ReadSecondaryWithContext(ctx, t, address)
+	ds.Port = port
+	return ds
+}
+
+// ReadSecondaryWithContext is ReadSecondaryWith that stops waiting for the answer when
+// the context is done; the transport stays open.
+func ReadSecondaryWithContext(ctx context.Context, t Transport, address SecondaryAddress) DeviceState {
+	ct, release := withContext(ctx, t)
+	defer release()
+
+	ds := DeviceState{}
+	ds.Address = int(AFieldNetworkLayerAddress)
+	ds.SecondaryAddress = address.String()
+	ds.Timestamp = time.Now()
+	data, err := readSecondaryDeviceState(ct, address, transportTiming(t))
+	if err != nil {
+		ds.Error = err.Error()
+	}
+	ds.Data = data
+	return ds
+}
+
+// SetPrimaryAddress changes the primary address of the device and checks that it answers at the new address.
+func SetPrimaryAddress(port string, address int, newAddress int) error {
+	return SetPrimaryAddressContext(context.Background(), port, address, newAddress)
+}
+
+// SetPrimaryAddressWith changes the primary address of the device using an already open transport.
+func SetPrimaryAddressWith(t Transport, address int, newAddress int) error {
+	return SetPrimaryAddressWithContext(context.Background(), t, address, newAddress)
+}
+
+// SetPrimaryAddressContext is SetPrimaryAddress that stops waiting for the port or the
+// answer when the context is done and closes the port immediately.
+func SetPrimaryAddressContext(ctx context.Context, port string, address int, newAddress int) error {
+	t, err := openTransportWaitContext(ctx, port)
+	if err != nil {
+		return err
+	}
+	t, release := ownTransport(ctx, t)
+	defer release()
+
+	return SetPrimaryAddressWithContext(ctx, t, address, newAddress)
+}
+
+// SetPrimaryAddressWithContext is SetPrimaryAddressWith that stops waiting for the answer
+// when the context is done; the transport stays open.
+func SetPrimaryAddressWithContext(ctx context.Context, t Transport, address int, newAddress int) error {
+	ct, release := withContext(ctx, t)
+	defer release()
+
+	return setPrimaryAddress(ct, uint(address), uint(newAddress), transportTiming(t))
+}
+
+// SetPrimaryAddressSecondary selects the device by its secondary address and changes its primary address.
+// The secondary address must not contain wildcards.
+func SetPrimaryAddressSecondary(port string, address SecondaryAddress, newAddress int) error {
+	return SetPrimaryAddressSecondaryContext(context.Background(), port, address, newAddress)
+}
+
+// SetPrimaryAddressSecondaryWith selects the device by its secondary address and changes its
+// primary address using an already open transport.
+func SetPrimaryAddressSecondaryWith(t Transport, address SecondaryAddress, newAddress int) error {
+	return SetPrimaryAddressSecondaryWithContext(context.Background(), t, address, newAddress)
+}
+
+// SetPrimaryAddressSecondaryContext is SetPrimaryAddressSecondary that stops waiting for
+// the port or the answer when the context is done and closes the port immediately.
+func SetPrimaryAddressSecondaryContext(ctx context.Context, port string, address SecondaryAddress, newAddress int) error {
+	t, err := openTransportWaitContext(ctx, port)
+	if err != nil {
+		return err
+	}
+	t, release := ownTransport(ctx, t)
+	defer release()
+
+	return SetPrimaryAddressSecondaryWithContext(ctx, t, address, newAddress)
+}
+
+// SetPrimaryAddressSecondaryWithContext is SetPrimaryAddressSecondaryWith that stops waiting
+// for the answer when the context is done; the transport stays open.
+func SetPrimaryAddressSecondaryWithContext(ctx context.Context, t Transport, address SecondaryAddress, newAddress int) error {
+	ct, release := withContext(ctx, t)
+	defer release()
+
+	_, err := setPrimaryAddressSecondary(ct, address, uint(newAddress), transportTiming(t))
+	return err
+}
+
+// SwitchBaudRate commands the device to change its baud rate. The port is opened at 2400 baud.
+func SwitchBaudRate(port string, address int, baud int) error {
+	return SwitchBaudRateContext(context.Background(), port, address, baud)
+}
+
+// SwitchBaudRateWith commands the device to change its baud rate using an already open transport.
+// The transport must support BaudRateSetter, after the ACK it continues at the new rate.
+func SwitchBaudRateWith(t Transport, address int, baud int) error {
+	return SwitchBaudRateWithContext(context.Background(), t, address, baud)
+}
+
+// SwitchBaudRateContext is SwitchBaudRate that stops waiting for the port or the answer
+// when the context is done and closes the port immediately.
+func SwitchBaudRateContext(ctx context.Context, port string, address int, baud int) error {
+	t, err := openTransportWaitContext(ctx, port)
+	if err != nil {
+		return err
+	}
+	t, release := ownTransport(ctx, t)
+	defer release()
+
+	return SwitchBaudRateWithContext(ctx, t, address, baud)
+}
+
+// SwitchBaudRateWithContext is SwitchBaudRateWith that stops waiting for the answer when
+// the context is done; the transport stays open.
+func SwitchBaudRateWithContext(ctx context.Context, t Transport, address int, baud int) error {
+	setBaud, err := transportBaud(t)
+	if err != nil {
+		return err
+	}
+	ct, release := withContext(ctx, t)
+	defer release()
+
+	if err := switchBaudRate(ct, uint(address), baud, transportTiming(t)); err != nil {
+		return err
+	}
+	return setBaud(baud)
+}
+
+// DetectBaudRate finds the baud rate of the device by sending SND_NKE at each of BaudRates.
+func DetectBaudRate(port string, address int) (int, error) {
+	return DetectBaudRateContext(context.Background(), port, address)
+}
+
+// DetectBaudRateWith finds the baud rate of the device using an already open transport.
+// The transport must support BaudRateSetter, it is left at the detected rate.
+func DetectBaudRateWith(t Transport, address int) (int, error) {
+	return DetectBaudRateWithContext(context.Background(), t, address)
+}
+
+// DetectBaudRateContext is DetectBaudRate that stops trying the rates when the context
+// is done and closes the port immediately.
+func DetectBaudRateContext(ctx context.Context, port string, address int) (int, error) {
+	t, err := openTransportWaitContext(ctx, port)
+	if err != nil {
+		return 0, err
+	}
+	t, release := ownTransport(ctx, t)
+	defer release()
+
+	return DetectBaudRateWithContext(ctx, t, address)
+}
+
+// DetectBaudRateWithContext is DetectBaudRateWith that stops trying the rates when the
+// context is done; the transport stays open.
+func DetectBaudRateWithContext(ctx context.Context, t Transport, address int) (int, error) {
+	setBaud, err := transportBaud(t)
+	if err != nil {
+		return 0, err
+	}
+	ct, release := withContext(ctx, t)
+	defer release()
+
+	return detectBaudRate(ct, uint(address), BaudRates, setBaud)
+}
+
+// ScanBaudRates pings the addresses from first to last at each of BaudRates and reports
+// every device found with the rate it answered at.
+func ScanBaudRates(port string, first int, last int) ([]PingState, error) {
+	return ScanBaudRatesContext(context.Background(), port, first, last)
+}
+
+// ScanBaudRatesWith pings the addresses at each of BaudRates using an already open transport.
+// The transport must support BaudRateSetter.
+func ScanBaudRatesWith(t Transport, first int, last int) ([]PingState, error) {
+	return ScanBaudRatesWithContext(context.Background(), t, first, last)
+}
+
+// ScanBaudRatesContext is ScanBaudRates that stops the scan when the context is done and
+// closes the port immediately. The devices found so far are returned with the error.
+func ScanBaudRatesContext(ctx context.Context, port string, first int, last int) ([]PingState, error) {
+	t, err := openTransportWaitContext(ctx, port)
+	if err != nil {
+		return nil, err
+	}
+	t, release := ownTransport(ctx, t)
+	defer release()
+
+	found, err := ScanBaudRatesWithContext(ctx, t, first, last)
+	for i := range found {
+		found[i].Port = port
+	}
+	return found, err
+}
+
+// ScanBaudRatesWithContext is ScanBaudRatesWith that stops the scan when the context is
+// done; the transport stays open.
+func ScanBaudRatesWithContext(ctx context.Context, t Transport, first int, last int) ([]PingState, error) {
+	if first < 0 || last > 250 || first > last {
+		return nil, fmt.Errorf("%w: %d-%d", ErrInvalidSlaveAddress, first, last)
+	}
+	setBaud, err := transportBaud(t)
+	if err != nil {
+		return nil, err
+	}
+	ct, release := withContext(ctx, t)
+	defer release()
+
+	return scanBaudRates(ct, first, last, BaudRates, setBaud)
+}
+
+// ApplicationReset resets the application of the device. The subcode selects the data the
+// device returns on the next REQ_UD2.
+func ApplicationReset(port string, address int, subcode ResetSubcode) error {
+	return ApplicationResetContext(context.Background(), port, address, subcode)
+}
+
+// ApplicationResetWith resets the application of the device using an already open transport.
+func ApplicationResetWith(t Transport, address int, subcode ResetSubcode) error {
+	return ApplicationResetWithContext(context.Background(), t, address, subcode)
+}
+
+// ApplicationResetContext is ApplicationReset that stops waiting for the port or the
+// answer when the context is done and closes the port immediately.
+func ApplicationResetContext(ctx context.Context, port string, address int, subcode ResetSubcode) error {
+	t, err := openTransportWaitContext(ctx, port)
+	if err != nil {
+		return err
+	}
+	t, release := ownTransport(ctx, t)
+	defer release()
+
+	return ApplicationResetWithContext(ctx, t, address, subcode)
+}
+
+// ApplicationResetWithContext is ApplicationResetWith that stops waiting for the answer
+// when the context is done; the transport stays open.
+func ApplicationResetWithContext(ctx context.Context, t Transport, address int, subcode ResetSubcode) error {
+	ct, release := withContext(ctx, t)
+	defer release()
+
+	return applicationReset(ct, uint(address), subcode, transportTiming(t))
+}
+
+// SetDateTime writes the date and time to the device as CP32 or CP48 time point.
+// The wall clock of dateTime is sent, pass it in the time zone of the meter.
+func SetDateTime(port string, address int, dateTime time.Time, format TimePointType) error {
+	return SetDateTimeContext(context.Background(), port, address, dateTime, format)
+}
+
+// SetDateTimeWith writes the date and time to the device using an already open transport.
+func SetDateTimeWith(t Transport, address int, dateTime time.Time, format TimePointType) error {
+	return SetDateTimeWithContext(context.Background(), t, address, dateTime, format)
+}
+
+// SetDateTimeContext is SetDateTime that stops waiting for the port or the answer when
+// the context is done and closes the port immediately.
+func SetDateTimeContext(ctx context.Context, port string, address int, dateTime time.Time, format TimePointType) error {
+	t, err := openTransportWaitContext(ctx, port)
+	if err != nil {
+		return err
+	}
+	t, release := ownTransport(ctx, t)
+	defer release()
+
+	return SetDateTimeWithContext(ctx, t, address, dateTime, format)
+}
+
+// SetDateTimeWithContext is SetDateTimeWith that stops waiting for the answer when the
+// context is done; the transport stays open.
+func SetDateTimeWithContext(ctx context.Context, t Transport, address int, dateTime time.Time, format TimePointType) error {
+	ct, release := withContext(ctx, t)
+	defer release()
+
+	return setDateTime(ct, uint(address), dateTime, format, transportTiming(t))
+}
+
+// Synchronize broadcasts the date and time to all devices on the port (CI 0x54 to address 255).
+func Synchronize(port string, dateTime time.Time, format TimePointType) error {
+	return SynchronizeContext(context.Background(), port, dateTime, format)
+}
+
+// SynchronizeWith broadcasts the date and time to all devices using an already open transport.
+func SynchronizeWith(t Transport, dateTime time.Time, format TimePointType) error {
+	return SynchronizeWithContext(context.Background(), t, dateTime, format)
+}
+
+// SynchronizeContext is Synchronize that stops waiting for the port when the context is
+// done and closes the port immediately.
+func SynchronizeContext(ctx context.Context, port string, dateTime time.Time, format TimePointType) error {
+	t, err := openTransportWaitContext(ctx, port)
+	if err != nil {
+		return err
+	}
+	t, release := ownTransport(ctx, t)
+	defer release()
+
+	return SynchronizeWithContext(ctx, t, dateTime, format)
+}
+
+// SynchronizeWithContext is SynchronizeWith that does not send the broadcast when the
+// context is done; the transport stays open.
+func SynchronizeWithContext(ctx context.Context, t Transport, dateTime time.Time, format TimePointType) error {
+	ct, release := withContext(ctx, t)
+	defer release()
+
+	return synchronize(ct, dateTime, format, transportTiming(t))
+}
+
+// ReadAlarm requests class 1 data (REQ_UD1) from the device and reports its alarm status.
+// It always sends the frame count bit 0, poll with Bus.ReadAlarm or ReadAlarmWith.
+func ReadAlarm(port string, address int) AlarmState {
+	return ReadAlarmContext(context.Background(), port, address)
+}
+
+// ReadAlarmWith requests class 1 data from the device using an already open transport.
+// Pass the returned frame count bit to the next request, so each alarm is reported once.
+func ReadAlarmWith(t Transport, address int, fcb bool) (AlarmState, bool) {
+	return ReadAlarmWithContext(context.Background(), t, address, fcb)
+}
+
+// ReadAlarmContext is ReadAlarm that stops waiting for the port or the answer when the
+// context is done and closes the port immediately.
+func ReadAlarmContext(ctx context.Context, port string, address int) AlarmState {
+	t, err := openTransportWaitContext(ctx, port)
+	if err != nil {
+		return AlarmState{Port: port, Address: address, Timestamp: time.Now(), Error: err.Error()}
+	}
+	t, release := ownTransport(ctx, t)
+	defer release()
+
+	state, _ := ReadAlarmWithContext(ctx, t, address, false)
+	state.Port = port
+	return state
+}
+
+// ReadAlarmWithContext is ReadAlarmWith that stops waiting for the answer when the
+// context is done; the transport stays open.
+func ReadAlarmWithContext(ctx context.Context, t Transport, address int, fcb bool) (AlarmState, bool) {
+	ct, release := withContext(ctx, t)
+	defer release()
+
+	state, err := readAlarm(ct, uint(address), fcb, transportTiming(t))
+	if err != nil {
+		state.Error = err.Error()
+		return state, fcb
+	}
+	return state, !fcb
+}
+
+// ReadSelected sends the record selectors to the device and reads its data. A device that
+// supports selection for readout returns only the selected records.
+func ReadSelected(port string, address int, selectors []RecordSelector) DeviceState {
+	return ReadSelectedContext(context.Background(), port, address, selectors)
+}
+
+// ReadSelectedWith sends the record selectors and reads the data using an already open transport.
+func ReadSelectedWith(t Transport, address int, selectors []RecordSelector) DeviceState {
+	return ReadSelectedWithContext(context.Background(), t, address, selectors)
+}
+
+// ReadSelectedContext is ReadSelected that stops waiting for the port or the answer when
+// the context is done and closes the port immediately.
+func ReadSelectedContext(ctx context.Context, port string, address int, selectors []RecordSelector) DeviceState {
+	t, err := openTransportWaitContext(ctx, port)
+	if err != nil {
+		return DeviceState{Port: port, Address: address, Timestamp: time.Now(), Error: err.Error()}
+	}
+	t, release := ownTransport(ctx, t)
+	defer release()
+
+	ds := ReadSelectedWithContext(ctx, t, address, selectors)
+	ds.Port = port
+	return ds
+}
+
+// ReadSelectedWithContext is ReadSelectedWith that stops waiting for the answer when the
+// context is done; the transport stays open.
+func ReadSelectedWithContext(ctx context.Context, t Transport, address int, selectors []RecordSelector) DeviceState {
+	ct, release := withContext(ctx, t)
+	defer release()
+
+	ds := DeviceState{Address: address, Timestamp: time.Now()}
+	data, _, err := readSelected(ct, uint(address), selectors, false, transportTiming(t))
+	if err != nil {
+		ds.Error = err.Error()
+	}
+	ds.Data = data
+	return ds
+}
+
+// WriteRecords writes the data records to the device with SND_UD and waits for the ACK.
+func WriteRecords(port string, address int, records []RecordWrite) error {
+	return WriteRecordsContext(context.Background(), port, address, records)
+}
+
+// WriteRecordsWith writes the data records to the device using an already open transport.
+func WriteRecordsWith(t Transport, address int, records []RecordWrite) error {
+	return WriteRecordsWithContext(context.Background(), t, address, records)
+}
+
+// WriteRecordsContext is WriteRecords that stops waiting for the port or the answer when
+// the context is done and closes the port immediately.
+func WriteRecordsContext(ctx context.Context, port string, address int, records []RecordWrite) error {
+	t, err := openTransportWaitContext(ctx, port)
+	if err != nil {
+		return err
+	}
+	t, release := ownTransport(ctx, t)
+	defer release()
+
+	return WriteRecordsWithContext(ctx, t, address, records)
+}
+
+// WriteRecordsWithContext is WriteRecordsWith that stops waiting for the answer when the
+// context is done; the transport stays open.
+func WriteRecordsWithContext(ctx context.Context, t Transport, address int, records []RecordWrite) error {
+	ct, release := withContext(ctx, t)
+	defer release()
+
+	return writeRecords(ct, uint(address), records, transportTiming(t))
+}
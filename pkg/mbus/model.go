@@ -6,14 +6,34 @@ type PingState struct {
 	Port      string    `json:"port"`
 	Address   int       `json:"address"`
 	State     bool      `json:"state"`
+	Collision bool      `json:"collision"`
+	BaudRate  int       `json:"baud_rate,omitempty"`
 	Timestamp time.Time `json:"timestamp"`
 	Error     string    `json:"error"`
 }
 
 type DeviceState struct {
-	Port      string       `json:"port"`
-	Address   int          `json:"address"`
-	Data      LFrameParsed `json:"data"`
-	Timestamp time.Time    `json:"timestamp"`
-	Error     string       `json:"error"`
+	Port             string       `json:"port"`
+	Address          int          `json:"address"`
+	SecondaryAddress string       `json:"secondary_address,omitempty"`
+	Data             LFrameParsed `json:"data"`
+	Timestamp        time.Time    `json:"timestamp"`
+	Error            string       `json:"error"`
+}
+
+// AlarmState is the answer of a slave to REQ_UD1
+type AlarmState struct {
+	Port    string `json:"port"`
+	Address int    `json:"address"`
+	// Alarm is true if the slave reported a status other than 0
+	Alarm bool `json:"alarm"`
+	// Status is the alarm status of a CI 0x71 report or the status byte of a
+	// CI 0x72 telegram, 0 if the slave only acknowledged
+	Status byte `json:"status"`
+	// CIField of the answer, 0 if the slave only acknowledged
+	CIField byte `json:"ci_field"`
+	// Data are the bytes after the CI field
+	Data      []byte    `json:"data,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Error     string    `json:"error"`
 }
@@ -0,0 +1,227 @@
+package mbus
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrNoResponse is returned when no byte arrives within the response timeout
+var ErrNoResponse = errors.New("no response from slave")
+
+// ErrIncompleteFrame is returned when the slave stops sending in the middle of a frame
+var ErrIncompleteFrame = errors.New("incomplete frame")
+
+// ErrUnknownFrame is returned when the first byte is not a known start byte
+var ErrUnknownFrame = errors.New("unknown frame start byte")
+
+// transmissionMargin is added to the EN 13757-2 timeouts to cover the latency
+// of USB adapters and network gateways
+const transmissionMargin = 100 * time.Millisecond
+
+// Timing are the link layer timeouts of the master for one baud rate
+type Timing struct {
+	// Response is how long the master waits for the first byte of the answer
+	Response time.Duration
+	// InterCharacter is the longest pause allowed between two bytes of a frame
+	InterCharacter time.Duration
+}
+
+// TimingForBaud returns the timeouts for the baud rate. EN 13757-2 allows the
+// slave to answer within 330 bit times + 50 ms and to pause up to 11 bit times
+// between characters.
+func TimingForBaud(baud int) Timing {
+	return Timing{
+		Response:       bitTimes(330, baud) + 50*time.Millisecond + transmissionMargin,
+		InterCharacter: bitTimes(11, baud) + transmissionMargin,
+	}
+}
+
+// responseTimeoutTransport is a transport with its own response timeout, see WithResponseTimeout
+type responseTimeoutTransport struct {
+	Transport
+	response time.Duration
+}
+
+// WithResponseTimeout returns the transport with a response timeout that replaces the one
+// of TimingForBaud in the functions on an open transport, e.g. ReadWith, for a gateway with
+// a long latency. DetectBaudRate and ScanBaudRates keep the timing of each rate, a Bus
+// uses RetryPolicy.ResponseTimeout.
+func WithResponseTimeout(t Transport, timeout time.Duration) Transport {
+	return &responseTimeoutTransport{Transport: t, response: timeout}
+}
+
+// SetBaudRate forwards to the wrapped transport
+func (rt *responseTimeoutTransport) SetBaudRate(baud int) error {
+	setter, ok := rt.Transport.(BaudRateSetter)
+	if !ok {
+		return ErrBaudRateFixed
+	}
+	return setter.SetBaudRate(baud)
+}
+
+// transportTiming returns the timeouts of the functions without a bus on the transport
+func transportTiming(t Transport) Timing {
+	timing := TimingForBaud(defaultBaud)
+	if rt, ok := t.(*responseTimeoutTransport); ok && rt.response > 0 {
+		timing.Response = rt.response
+	}
+	return timing
+}
+
+// bitTimes returns the duration of n bits at the baud rate
+func bitTimes(n int, baud int) time.Duration {
+	return time.Duration(n) * time.Second / time.Duration(baud)
+}
+
+// frameLength returns the total length of the frame starting at data[0].
+// It returns 0 if more bytes are needed to tell the length.
+func frameLength(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+	switch data[0] {
+	case FRAME_ACK_START:
+		return 1, nil
+	case FRAME_SHORT_START:
+		return 5, nil
+	case FRAME_LONG_START:
+		if len(data) < 2 {
+			return 0, nil
+		}
+		// L-field counts C, A, CI and user data
+		return int(data[1]) + 6, nil
+	}
+	return 0, fmt.Errorf("%w: 0x%02X", ErrUnknownFrame, data[0])
+}
+
+// readFrame reads one frame from the transport. It returns as soon as the
+// last byte of the frame arrives; bytes after the frame are discarded.
+func readFrame(t Transport, timing Timing) ([]byte, error) {
+	frame, _, err := receiveFrame(t, timing)
+	return frame, err
+}
+
+// receiveFrame reads one frame from the transport and returns the bytes that
+// arrived together with the frame separately
+func receiveFrame(t Transport, timing Timing) ([]byte, []byte, error) {
+	var data []byte
+	buf := make([]byte, rtuMaxSize)
+	timeout := timing.Response
+	for {
+		if err := t.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+			return data, nil, err
+		}
+		n, err := t.Read(buf)
+		data = append(data, buf[:n]...)
+
+		length, lengthErr := frameLength(data)
+		if lengthErr != nil {
+			return data, nil, lengthErr
+		}
+		if length > 0 && len(data) >= length {
+			return data[:length], data[length:], nil
+		}
+
+		if err != nil {
+			if isTimeout(err) || errors.Is(err, io.EOF) {
+				if len(data) == 0 {
+					return data, nil, ErrNoResponse
+				}
+				return data, nil, ErrIncompleteFrame
+			}
+			return data, nil, err
+		}
+		timeout = timing.InterCharacter
+	}
+}
+
+// drain discards bytes until the bus is quiet for the inter-character time and
+// returns how many bytes were discarded
+func drain(t Transport, timing Timing) int {
+	discarded := 0
+	buf := make([]byte, rtuMaxSize)
+	for {
+		if err := t.SetReadDeadline(time.Now().Add(timing.InterCharacter)); err != nil {
+			return discarded
+		}
+		n, err := t.Read(buf)
+		discarded += n
+		if n == 0 || err != nil {
+			return discarded
+		}
+	}
+}
+
+// ErrCollision is returned when several slaves answer at the same time
+var ErrCollision = errors.New("collision: several slaves answered")
+
+// Response is the class of the answer of the slaves to a request
+type Response int
+
+const (
+	// ResponseNone no slave answered
+	ResponseNone Response = iota
+	// ResponseAck one slave answered with the single character 0xE5
+	ResponseAck
+	// ResponseFrame one slave answered with a valid frame
+	ResponseFrame
+	// ResponseCollision the answer is garbled: an invalid or incomplete frame,
+	// an unknown start byte or several ACKs
+	ResponseCollision
+)
+
+func (r Response) String() string {
+	switch r {
+	case ResponseNone:
+		return "no response"
+	case ResponseAck:
+		return "ACK"
+	case ResponseFrame:
+		return "frame"
+	case ResponseCollision:
+		return "collision"
+	}
+	return fmt.Sprintf("Response(%d)", int(r))
+}
+
+// readResponse reads the answer of the slaves and classifies it. A garbled
+// answer is returned as ResponseCollision together with ErrCollision. An ACK
+// is returned as soon as it arrives, see readProbeResponse for scans.
+func readResponse(t Transport, timing Timing) ([]byte, Response, error) {
+	data, rest, err := receiveFrame(t, timing)
+	switch {
+	case errors.Is(err, ErrNoResponse):
+		return nil, ResponseNone, nil
+	case errors.Is(err, ErrIncompleteFrame) || errors.Is(err, ErrUnknownFrame):
+		drain(t, timing)
+		return data, ResponseCollision, fmt.Errorf("%w: %w", ErrCollision, err)
+	case err != nil:
+		return data, ResponseNone, err
+	}
+
+	if _, err := ParseFrame(data); err != nil {
+		drain(t, timing)
+		return data, ResponseCollision, fmt.Errorf("%w: %w", ErrCollision, err)
+	}
+	if data[0] != FRAME_ACK_START {
+		return data, ResponseFrame, nil
+	}
+	if len(rest) > 0 {
+		return data, ResponseCollision, fmt.Errorf("%w: more than one ACK", ErrCollision)
+	}
+	return data, ResponseAck, nil
+}
+
+// readProbeResponse is readResponse for scans and collision checks. The ACKs of
+// several slaves overlap into one 0xE5 only if they start at exactly the same
+// time, otherwise more bytes follow, so after an ACK it waits the inter-character
+// time for them.
+func readProbeResponse(t Transport, timing Timing) ([]byte, Response, error) {
+	data, response, err := readResponse(t, timing)
+	if response == ResponseAck && drain(t, timing) > 0 {
+		return data, ResponseCollision, fmt.Errorf("%w: more than one ACK", ErrCollision)
+	}
+	return data, response, err
+}
@@ -0,0 +1,264 @@
+package mbus
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimingForBaud(t *testing.T) {
+	tests := []struct {
+		name               string
+		baud               int
+		wantResponse       time.Duration
+		wantInterCharacter time.Duration
+	}{
+		{
+			name:               "300 baud",
+			baud:               300,
+			wantResponse:       1100*time.Millisecond + 50*time.Millisecond + transmissionMargin,
+			wantInterCharacter: 36666666*time.Nanosecond + transmissionMargin,
+		},
+		{
+			name:               "2400 baud",
+			baud:               2400,
+			wantResponse:       137500*time.Microsecond + 50*time.Millisecond + transmissionMargin,
+			wantInterCharacter: 4583333*time.Nanosecond + transmissionMargin,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TimingForBaud(tt.baud)
+			if got.Response != tt.wantResponse {
+				t.Errorf("TimingForBaud() Response = %v, want %v", got.Response, tt.wantResponse)
+			}
+			if got.InterCharacter != tt.wantInterCharacter {
+				t.Errorf("TimingForBaud() InterCharacter = %v, want %v", got.InterCharacter, tt.wantInterCharacter)
+			}
+		})
+	}
+}
+
+func TestFrameLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    int
+		wantErr error
+	}{
+		{name: "Empty", data: []byte{}, want: 0},
+		{name: "ACK", data: []byte{0xE5}, want: 1},
+		{name: "Short frame", data: []byte{0x10}, want: 5},
+		{name: "Long frame without L-field", data: []byte{0x68}, want: 0},
+		{name: "Control frame", data: []byte{0x68, 0x03}, want: 9},
+		{name: "Long frame", data: []byte{0x68, 0x15, 0x15}, want: 27},
+		{name: "Unknown start byte", data: []byte{0x42}, wantErr: ErrUnknownFrame},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := frameLength(tt.data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("frameLength() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("frameLength() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadFrame(t *testing.T) {
+	tests := []struct {
+		name    string
+		chunks  [][]byte
+		want    []byte
+		wantErr error
+	}{
+		{
+			name:   "ACK",
+			chunks: [][]byte{{0xE5}},
+			want:   []byte{0xE5},
+		},
+		{
+			name:   "Short frame",
+			chunks: [][]byte{{0x10, 0x40, 0x01, 0x41, 0x16}},
+			want:   []byte{0x10, 0x40, 0x01, 0x41, 0x16},
+		},
+		{
+			name:   "Long frame in chunks",
+			chunks: [][]byte{testRspUd[:2], testRspUd[2:10], testRspUd[10:]},
+			want:   testRspUd,
+		},
+		{
+			name:   "Garbage after stop byte is discarded",
+			chunks: [][]byte{append(append([]byte{}, testRspUd...), 0x00, 0xFF)},
+			want:   testRspUd,
+		},
+		{
+			name:    "No response",
+			chunks:  nil,
+			wantErr: ErrNoResponse,
+		},
+		{
+			name:    "Incomplete frame",
+			chunks:  [][]byte{testRspUd[:10]},
+			wantErr: ErrIncompleteFrame,
+		},
+		{
+			name:    "Unknown start byte",
+			chunks:  [][]byte{{0x42, 0x43}},
+			wantErr: ErrUnknownFrame,
+		},
+	}
+
+	timing := Timing{Response: 200 * time.Millisecond, InterCharacter: 50 * time.Millisecond}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, slave := NewPipeTransport()
+			defer tr.Close()
+			go func() {
+				defer slave.Close()
+				for _, chunk := range tt.chunks {
+					if _, err := slave.Write(chunk); err != nil {
+						return
+					}
+					time.Sleep(10 * time.Millisecond)
+				}
+				// Keep the pipe open until the reader gives up
+				time.Sleep(300 * time.Millisecond)
+			}()
+
+			got, err := readFrame(tr, timing)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("readFrame() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !bytes.Equal(got, tt.want) {
+				t.Errorf("readFrame() = % X, want % X", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadWith_ReturnsOnStopByte(t *testing.T) {
+	tr, slave := NewPipeTransport()
+	defer tr.Close()
+	fakeSlave(t, slave, func(request []byte) []byte {
+		return testRspUd
+	})
+
+	start := time.Now()
+	ds := ReadWith(tr, 1)
+	if ds.Error != "" {
+		t.Fatalf("ReadWith() error = %s", ds.Error)
+	}
+	if elapsed := time.Since(start); elapsed > TimingForBaud(defaultBaud).Response {
+		t.Errorf("ReadWith() took %v, want less than the response timeout", elapsed)
+	}
+}
+
+func TestResponseTimeout(t *testing.T) {
+	tr, slave := NewPipeTransport()
+	defer tr.Close()
+	fakeSlave(t, slave, func(request []byte) []byte { return nil })
+
+	timeout := 20 * time.Millisecond
+	start := time.Now()
+	ds := ReadWith(WithResponseTimeout(tr, timeout), 1)
+	if ds.Error != ErrNoResponse.Error() {
+		t.Fatalf("ReadWith() error = %q, want %q", ds.Error, ErrNoResponse)
+	}
+	if elapsed := time.Since(start); elapsed >= TimingForBaud(defaultBaud).Response {
+		t.Errorf("ReadWith() took %v, want about %v", elapsed, timeout)
+	}
+}
+
+func TestReadResponse(t *testing.T) {
+	corrupt := append([]byte{}, testRspUd...)
+	corrupt[20]++
+
+	tests := []struct {
+		name    string
+		chunks  [][]byte
+		want    Response
+		wantErr error
+	}{
+		{name: "No response", chunks: nil, want: ResponseNone},
+		{name: "ACK", chunks: [][]byte{{0xE5}}, want: ResponseAck},
+		{name: "Long frame", chunks: [][]byte{testRspUd}, want: ResponseFrame},
+		{name: "Two ACKs at once", chunks: [][]byte{{0xE5, 0xE5}}, want: ResponseCollision, wantErr: ErrCollision},
+		{name: "Two ACKs one after the other", chunks: [][]byte{{0xE5}, {0xE5}}, want: ResponseAck},
+		{name: "Garbled byte", chunks: [][]byte{{0xF5, 0x12}}, want: ResponseCollision, wantErr: ErrCollision},
+		{name: "Checksum error", chunks: [][]byte{corrupt}, want: ResponseCollision, wantErr: ErrCollision},
+		{name: "Incomplete frame", chunks: [][]byte{testRspUd[:10]}, want: ResponseCollision, wantErr: ErrCollision},
+	}
+
+	timing := Timing{Response: 100 * time.Millisecond, InterCharacter: 50 * time.Millisecond}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, slave := NewPipeTransport()
+			defer tr.Close()
+			go func() {
+				defer slave.Close()
+				for _, chunk := range tt.chunks {
+					if _, err := slave.Write(chunk); err != nil {
+						return
+					}
+					time.Sleep(10 * time.Millisecond)
+				}
+				time.Sleep(200 * time.Millisecond)
+			}()
+
+			_, got, err := readResponse(tr, timing)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("readResponse() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("readResponse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadProbeResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		chunks  [][]byte
+		want    Response
+		wantErr error
+	}{
+		{name: "No response", chunks: nil, want: ResponseNone},
+		{name: "ACK", chunks: [][]byte{{0xE5}}, want: ResponseAck},
+		{name: "Long frame", chunks: [][]byte{testRspUd}, want: ResponseFrame},
+		{name: "Two ACKs at once", chunks: [][]byte{{0xE5, 0xE5}}, want: ResponseCollision, wantErr: ErrCollision},
+		{name: "Two ACKs one after the other", chunks: [][]byte{{0xE5}, {0xE5}}, want: ResponseCollision, wantErr: ErrCollision},
+	}
+
+	timing := Timing{Response: 100 * time.Millisecond, InterCharacter: 50 * time.Millisecond}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, slave := NewPipeTransport()
+			defer tr.Close()
+			go func() {
+				defer slave.Close()
+				for _, chunk := range tt.chunks {
+					if _, err := slave.Write(chunk); err != nil {
+						return
+					}
+					time.Sleep(10 * time.Millisecond)
+				}
+				time.Sleep(200 * time.Millisecond)
+			}()
+
+			_, got, err := readProbeResponse(tr, timing)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("readProbeResponse() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("readProbeResponse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
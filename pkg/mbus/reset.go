@@ -0,0 +1,135 @@
+package mbus
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ResetSubcode is the optional data byte of an application reset (CI 0x50). It selects
+// the telegram type the slave returns on the next REQ_UD2. The upper nibble is the
+// type, the lower nibble the number of the telegram within the type (0 for all).
+type ResetSubcode byte
+
+const (
+	// ResetAll resets the application, the slave returns all data
+	ResetAll ResetSubcode = 0x00
+	// ResetUserData the slave returns user data
+	ResetUserData ResetSubcode = 0x10
+	// ResetSimpleBilling current and fixed date values and the dates
+	ResetSimpleBilling ResetSubcode = 0x20
+	// ResetEnhancedBilling historic values
+	ResetEnhancedBilling ResetSubcode = 0x30
+	// ResetMultiTariffBilling values of the tariffs
+	ResetMultiTariffBilling ResetSubcode = 0x40
+	// ResetInstantaneousValues values for regulation, e.g. power and flow
+	ResetInstantaneousValues ResetSubcode = 0x50
+	// ResetLoadManagement values for load management
+	ResetLoadManagement ResetSubcode = 0x60
+	// ResetInstallation installation and startup data, e.g. bus address and fabrication number
+	ResetInstallation ResetSubcode = 0x80
+	// ResetTesting high resolution values for testing
+	ResetTesting ResetSubcode = 0x90
+	// ResetCalibration values for calibration
+	ResetCalibration ResetSubcode = 0xA0
+	// ResetManufacturing values for manufacturing
+	ResetManufacturing ResetSubcode = 0xB0
+	// ResetDevelopment values for development
+	ResetDevelopment ResetSubcode = 0xC0
+	// ResetSelftest values of the self test
+	ResetSelftest ResetSubcode = 0xD0
+)
+
+// ErrInvalidResetSubcode is returned for a subcode with a reserved telegram type
+var ErrInvalidResetSubcode = errors.New("invalid application reset subcode")
+
+// Type returns the subcode without the telegram number
+func (s ResetSubcode) Type() ResetSubcode {
+	return s & 0xF0
+}
+
+// Telegram returns the number of the telegram within the type, 0 means all telegrams
+func (s ResetSubcode) Telegram() int {
+	return int(s & 0x0F)
+}
+
+// WithTelegram returns the subcode that selects telegram n (1..15) of the type
+func (s ResetSubcode) WithTelegram(n int) ResetSubcode {
+	return s.Type() | ResetSubcode(n&0x0F)
+}
+
+// Valid returns false for the reserved telegram types 0x7, 0xE and 0xF
+func (s ResetSubcode) Valid() bool {
+	switch s.Type() {
+	case 0x70, 0xE0, 0xF0:
+		return false
+	}
+	return true
+}
+
+func (s ResetSubcode) String() string {
+	var name string
+	switch s.Type() {
+	case ResetAll:
+		name = "all"
+	case ResetUserData:
+		name = "user data"
+	case ResetSimpleBilling:
+		name = "simple billing"
+	case ResetEnhancedBilling:
+		name = "enhanced billing"
+	case ResetMultiTariffBilling:
+		name = "multi tariff billing"
+	case ResetInstantaneousValues:
+		name = "instantaneous values"
+	case ResetLoadManagement:
+		name = "load management"
+	case ResetInstallation:
+		name = "installation and startup"
+	case ResetTesting:
+		name = "testing"
+	case ResetCalibration:
+		name = "calibration"
+	case ResetManufacturing:
+		name = "manufacturing"
+	case ResetDevelopment:
+		name = "development"
+	case ResetSelftest:
+		name = "selftest"
+	default:
+		return fmt.Sprintf("ResetSubcode(0x%02X)", byte(s))
+	}
+	if s.Telegram() != 0 {
+		return fmt.Sprintf("%s, telegram %d", name, s.Telegram())
+	}
+	return name
+}
+
+// COMMAND_APPLICATION_RESET Reset the application of the slave with SND_UD CI 0x50.
+// ResetAll is sent without data, any other subcode as one data byte.
+func COMMAND_APPLICATION_RESET(deviceAddress uint, subcode ResetSubcode) ([]byte, error) {
+	data, err := resetData(subcode)
+	if err != nil {
+		return nil, err
+	}
+	return COMMAND_SND_UD(deviceAddress, CiFieldApplicationReset, data)
+}
+
+// resetData returns the data of the application reset, none for ResetAll
+func resetData(subcode ResetSubcode) ([]byte, error) {
+	if !subcode.Valid() {
+		return nil, fmt.Errorf("%w: 0x%02X", ErrInvalidResetSubcode, byte(subcode))
+	}
+	if subcode == ResetAll {
+		return nil, nil
+	}
+	return []byte{byte(subcode)}, nil
+}
+
+// applicationReset sends the application reset and waits for the ACK
+func applicationReset(t Transport, deviceAddress uint, subcode ResetSubcode, timing Timing) error {
+	data, err := resetData(subcode)
+	if err != nil {
+		return err
+	}
+	return sendUserData(t, deviceAddress, CiFieldApplicationReset, data, timing)
+}
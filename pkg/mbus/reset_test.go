@@ -0,0 +1,90 @@
+package mbus
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestCOMMAND_APPLICATION_RESET(t *testing.T) {
+	tests := []struct {
+		name    string
+		subcode ResetSubcode
+		want    []byte
+		wantErr error
+	}{
+		{
+			name:    "Reset all",
+			subcode: ResetAll,
+			want:    []byte{0x68, 0x03, 0x03, 0x68, 0x53, 0x01, 0x50, 0xA4, 0x16},
+		},
+		{
+			name:    "Instantaneous values",
+			subcode: ResetInstantaneousValues,
+			want:    []byte{0x68, 0x04, 0x04, 0x68, 0x53, 0x01, 0x50, 0x50, 0xF4, 0x16},
+		},
+		{
+			name:    "Second billing telegram",
+			subcode: ResetEnhancedBilling.WithTelegram(2),
+			want:    []byte{0x68, 0x04, 0x04, 0x68, 0x53, 0x01, 0x50, 0x32, 0xD6, 0x16},
+		},
+		{
+			name:    "Reserved type",
+			subcode: 0x70,
+			wantErr: ErrInvalidResetSubcode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := COMMAND_APPLICATION_RESET(1, tt.subcode)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("COMMAND_APPLICATION_RESET() error = %v, want %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("COMMAND_APPLICATION_RESET() = % X, want % X", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResetSubcode_String(t *testing.T) {
+	tests := []struct {
+		subcode ResetSubcode
+		want    string
+	}{
+		{ResetAll, "all"},
+		{ResetMultiTariffBilling, "multi tariff billing"},
+		{ResetSimpleBilling.WithTelegram(3), "simple billing, telegram 3"},
+		{0xE0, "ResetSubcode(0xE0)"},
+	}
+	for _, tt := range tests {
+		if got := tt.subcode.String(); got != tt.want {
+			t.Errorf("ResetSubcode(0x%02X).String() = %q, want %q", byte(tt.subcode), got, tt.want)
+		}
+	}
+}
+
+func TestBus_ApplicationReset(t *testing.T) {
+	tr, slave := NewPipeTransport()
+	var received ResetSubcode
+	fakeSlave(t, slave, func(request []byte) []byte {
+		if len(request) == 10 && request[5] == 1 && CIField(request[6]) == CiFieldApplicationReset {
+			received = ResetSubcode(request[7])
+			return []byte{FRAME_ACK_START}
+		}
+		return nil
+	})
+	bus := NewBus(tr)
+	defer bus.Close()
+
+	if err := bus.ApplicationReset(1, ResetInstallation); err != nil {
+		t.Fatalf("ApplicationReset() error = %v", err)
+	}
+	if received != ResetInstallation {
+		t.Errorf("slave received subcode %s, want %s", received, ResetInstallation)
+	}
+	if err := bus.ApplicationReset(2, ResetInstallation); !errors.Is(err, ErrNoAck) {
+		t.Errorf("ApplicationReset() at address 2 error = %v, want %v", err, ErrNoAck)
+	}
+}
@@ -0,0 +1,120 @@
+package mbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RetryPolicy tells the bus how to repeat a request that failed on the link layer
+type RetryPolicy struct {
+	// MaxAttempts is the number of tries of a request, 0 and 1 send it once
+	MaxAttempts int
+	// ResponseTimeout replaces the response timeout of TimingForBaud for each attempt if not 0
+	ResponseTimeout time.Duration
+	// Backoff is the pause before the first retry, it doubles with every further retry
+	Backoff time.Duration
+	// Retryable tells which errors are retried, nil means IsRetryable
+	Retryable func(err error) bool
+	// ResetBeforeRetry sends SND_NKE to the slave before a retry, so it starts again
+	// with a fresh frame count bit. It is not sent for requests by secondary address
+	// and never before a repeated write.
+	ResetBeforeRetry bool
+	// RetryWrites repeats writes as well: WriteRecords, SendUD, ApplicationReset,
+	// SetDateTime and Synchronize. A slave that lost only the ACK executes the write
+	// twice. Address and baud rate changes and Commission are never repeated.
+	RetryWrites bool
+}
+
+// DefaultRetryPolicy returns the policy of a new bus, it sends every request once
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 1}
+}
+
+// requestKind tells how the retry policy applies to a bus request
+type requestKind int
+
+const (
+	// requestRead does not change the slave and may be repeated: REQ_UD2, REQ_UD1,
+	// SND_NKE and the selection for a readout
+	requestRead requestKind = iota
+	// requestWrite changes the data of the slave, it is repeated only with RetryWrites
+	requestWrite
+	// requestOnce is never repeated, the slave may already answer at another
+	// address or baud rate
+	requestOnce
+)
+
+// forRequest returns the policy for the kind of request
+func (p RetryPolicy) forRequest(kind requestKind) RetryPolicy {
+	switch kind {
+	case requestWrite:
+		if !p.RetryWrites {
+			p.MaxAttempts = 1
+		}
+		p.ResetBeforeRetry = false
+	case requestOnce:
+		p.MaxAttempts = 1
+	}
+	return p
+}
+
+// IsRetryable returns true for errors of a missing or damaged answer: no response,
+// no ACK, an incomplete frame or a frame that does not verify. A collision with a
+// damaged frame is retried as well, noise on the bus looks the same.
+func IsRetryable(err error) bool {
+	for _, retryable := range []error{
+		ErrNoResponse, ErrNoAck, ErrIncompleteFrame,
+		ErrFrameStart, ErrFrameLength, ErrFrameChecksum, ErrFrameStop,
+	} {
+		if errors.Is(err, retryable) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p RetryPolicy) retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsRetryable(err)
+}
+
+// do runs fn until it succeeds, fails with an error that is not retryable or
+// MaxAttempts is reached. address is the slave for ResetBeforeRetry, busBaud for none.
+func (p RetryPolicy) do(ctx context.Context, t Transport, address int, timing Timing, fn func(t Transport, timing Timing) error) error {
+	if p.ResponseTimeout > 0 {
+		timing.Response = p.ResponseTimeout
+	}
+	backoff := p.Backoff
+	for attempt := 1; ; attempt++ {
+		err := fn(t, timing)
+		if err == nil || attempt >= p.MaxAttempts || !p.retryable(err) {
+			if err != nil && attempt > 1 {
+				return fmt.Errorf("%w (after %d attempts)", err, attempt)
+			}
+			return err
+		}
+
+		if backoff > 0 {
+			pause := time.NewTimer(backoff)
+			select {
+			case <-pause.C:
+			case <-ctx.Done():
+				pause.Stop()
+				return ctx.Err()
+			}
+			backoff *= 2
+		}
+		// Rest of a damaged answer
+		drain(t, timing)
+		if p.ResetBeforeRetry && address >= 0 && address <= 250 {
+			_, _ = probeAddress(t, uint(address), timing)
+		}
+	}
+}
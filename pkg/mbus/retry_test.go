@@ -0,0 +1,198 @@
+package mbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakySlave answers REQ_UD2 at address 1 with testRspUd, the first failures answers
+// with a damaged checksum. Address 2 never answers. It records the C and A field of
+// every request.
+func flakySlave(t *testing.T, failures int) (Transport, func() []string) {
+	tr, slave := NewPipeTransport()
+	var mu sync.Mutex
+	var requests []string
+	fakeSlave(t, slave, func(request []byte) []byte {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(request) != 5 {
+			return nil
+		}
+		requests = append(requests, fmt.Sprintf("%02X %d", request[1], request[2]))
+		if request[2] != 1 || request[1] == CFIELD_SND_NKE.getByte() {
+			return nil
+		}
+		if failures > 0 {
+			failures--
+			damaged := append([]byte{}, testRspUd...)
+			damaged[len(damaged)-2] ^= 0xFF
+			return damaged
+		}
+		return testRspUd
+	})
+	return tr, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, requests...)
+	}
+}
+
+func TestBus_SetRetryPolicy(t *testing.T) {
+	tr, requests := flakySlave(t, 2)
+	bus := NewBus(tr)
+	defer bus.Close()
+
+	if _, err := bus.ReadUD2(1); !errors.Is(err, ErrFrameChecksum) {
+		t.Fatalf("ReadUD2() without retry error = %v, want %v", err, ErrFrameChecksum)
+	}
+
+	if err := bus.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}); err != nil {
+		t.Fatalf("SetRetryPolicy() error = %v", err)
+	}
+	data, err := bus.ReadUD2(1)
+	if err != nil {
+		t.Fatalf("ReadUD2() with retry error = %v", err)
+	}
+	if data.IdentificationNumber != "12345678" {
+		t.Errorf("ReadUD2() identification number = %s, want 12345678", data.IdentificationNumber)
+	}
+	if got := len(requests()); got != 3 {
+		t.Errorf("slave received %d requests, want 3", got)
+	}
+}
+
+func TestBus_SetDeviceRetryPolicy(t *testing.T) {
+	tr, requests := flakySlave(t, 0)
+	bus := NewBus(tr)
+	defer bus.Close()
+
+	policy := RetryPolicy{MaxAttempts: 2, ResponseTimeout: 20 * time.Millisecond, ResetBeforeRetry: true}
+	if err := bus.SetDeviceRetryPolicy(2, policy); err != nil {
+		t.Fatalf("SetDeviceRetryPolicy() error = %v", err)
+	}
+
+	_, err := bus.ReadUD2(2)
+	if !errors.Is(err, ErrNoResponse) {
+		t.Fatalf("ReadUD2(2) error = %v, want %v", err, ErrNoResponse)
+	}
+	want := []string{"5B 2", "40 2", "5B 2"}
+	if got := requests(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("slave received %v, want %v", got, want)
+	}
+
+	// Other slaves keep the policy of the bus
+	if _, err := bus.ReadUD2(1); err != nil {
+		t.Errorf("ReadUD2(1) error = %v", err)
+	}
+	if got := len(requests()); got != 4 {
+		t.Errorf("slave received %d requests, want 4", got)
+	}
+}
+
+func TestRetryPolicy_BackoffCanceled(t *testing.T) {
+	tr, _ := flakySlave(t, 0)
+	bus := NewBus(tr)
+	defer bus.Close()
+
+	policy := RetryPolicy{MaxAttempts: 3, ResponseTimeout: 10 * time.Millisecond, Backoff: time.Minute}
+	if err := bus.SetRetryPolicy(policy); err != nil {
+		t.Fatalf("SetRetryPolicy() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := bus.ReadUD2Context(ctx, 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ReadUD2Context() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{ErrNoResponse, true},
+		{fmt.Errorf("%w: %w", ErrCollision, ErrFrameChecksum), true},
+		{ErrCollision, false},
+		{ErrAddressNotChanged, false},
+		{context.Canceled, false},
+	}
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestBus_RetryWrites(t *testing.T) {
+	tr, slave := NewPipeTransport()
+	var mu sync.Mutex
+	var requests []string
+	// No slave answers, every request is recorded as long or short frame with its C field
+	fakeSlave(t, slave, func(request []byte) []byte {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case len(request) == 5:
+			requests = append(requests, fmt.Sprintf("short %02X %d", request[1], request[2]))
+		case len(request) > 9 && request[0] == 0x68:
+			requests = append(requests, fmt.Sprintf("long %d", request[5]))
+		}
+		return nil
+	})
+	bus := NewBus(tr)
+	defer bus.Close()
+	received := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		got := requests
+		requests = nil
+		return got
+	}
+
+	policy := RetryPolicy{MaxAttempts: 3, ResponseTimeout: 20 * time.Millisecond, ResetBeforeRetry: true}
+	if err := bus.SetRetryPolicy(policy); err != nil {
+		t.Fatalf("SetRetryPolicy() error = %v", err)
+	}
+	records := []RecordWrite{{VIF: 0x13, DataField: DataFieldInt32}}
+
+	// Writes are sent once
+	if err := bus.WriteRecords(2, records); !errors.Is(err, ErrNoAck) {
+		t.Fatalf("WriteRecords() error = %v, want %v", err, ErrNoAck)
+	}
+	if got, want := received(), []string{"long 2"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("WriteRecords() sent %v, want %v", got, want)
+	}
+
+	// With RetryWrites they are repeated, but without SND_NKE in between
+	policy.RetryWrites = true
+	if err := bus.SetRetryPolicy(policy); err != nil {
+		t.Fatalf("SetRetryPolicy() error = %v", err)
+	}
+	if err := bus.WriteRecords(2, records); !errors.Is(err, ErrNoAck) {
+		t.Fatalf("WriteRecords() error = %v, want %v", err, ErrNoAck)
+	}
+	if got, want := received(), []string{"long 2", "long 2", "long 2"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("WriteRecords() with RetryWrites sent %v, want %v", got, want)
+	}
+
+	// A new primary address is never repeated: the free address 5 is probed and the write sent once
+	if err := bus.SetPrimaryAddress(2, 5); !errors.Is(err, ErrNoAck) {
+		t.Fatalf("SetPrimaryAddress() error = %v, want %v", err, ErrNoAck)
+	}
+	if got, want := received(), []string{"short 40 5", "long 2"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("SetPrimaryAddress() sent %v, want %v", got, want)
+	}
+
+	// Reads are still repeated with SND_NKE in between
+	if _, err := bus.ReadUD2(2); !errors.Is(err, ErrNoResponse) {
+		t.Fatalf("ReadUD2() error = %v, want %v", err, ErrNoResponse)
+	}
+	if got := received(); len(got) != 5 {
+		t.Errorf("ReadUD2() sent %v, want 3 requests and 2 SND_NKE", got)
+	}
+}
@@ -0,0 +1,86 @@
+package mbus
+
+import (
+	"bufio"
+	"io"
+)
+
+// ScanFrames is a bufio.SplitFunc that splits a continuous byte stream into
+// M-Bus frames. Bytes that do not start a valid frame are skipped, so the
+// scanner resynchronises on the next start byte after noise or a corrupt frame.
+// Each token is one complete frame that passed Verify.
+func ScanFrames(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	for start := 0; start < len(data); start++ {
+		if !isStartByte(data[start]) {
+			continue
+		}
+		frame := data[start:]
+		if frame[0] == FRAME_LONG_START && len(frame) >= 4 &&
+			(frame[1] != frame[2] || frame[3] != FRAME_LONG_START) {
+			continue
+		}
+		length, _ := frameLength(frame)
+		if length == 0 || len(frame) < length {
+			if atEOF {
+				// The stream ended inside this frame, look for a later one
+				continue
+			}
+			// Need more data, drop the noise before the start byte
+			return start, nil, nil
+		}
+		if _, err := ParseFrame(frame[:length]); err != nil {
+			continue
+		}
+		return start + length, frame[:length], nil
+	}
+	// Nothing found, all bytes are noise
+	return len(data), nil, nil
+}
+
+func isStartByte(b byte) bool {
+	return b == FRAME_ACK_START || b == FRAME_SHORT_START || b == FRAME_LONG_START
+}
+
+// FrameScanner reads the frames from a continuous stream, e.g. a TCP gateway
+// or a log file. It is used like bufio.Scanner:
+//
+//	s := NewFrameScanner(r)
+//	for s.Scan() {
+//		frame := s.Frame()
+//	}
+//	if err := s.Err(); err != nil {
+//		...
+//	}
+type FrameScanner struct {
+	scanner *bufio.Scanner
+	frame   Frame
+}
+
+// NewFrameScanner returns a FrameScanner reading from r.
+func NewFrameScanner(r io.Reader) *FrameScanner {
+	s := bufio.NewScanner(r)
+	s.Split(ScanFrames)
+	return &FrameScanner{scanner: s}
+}
+
+// Scan advances to the next frame. It returns false at the end of the stream or on a read error.
+func (s *FrameScanner) Scan() bool {
+	if !s.scanner.Scan() {
+		s.frame = nil
+		return false
+	}
+	// ScanFrames only returns verified frames
+	token := append([]byte{}, s.scanner.Bytes()...)
+	s.frame, _ = ParseFrame(token)
+	return true
+}
+
+// Frame returns the last frame found by Scan: *AckFrame, *SFrame, *CFrame or *LFrame.
+func (s *FrameScanner) Frame() Frame {
+	return s.frame
+}
+
+// Err returns the first read error, io.EOF is not an error.
+func (s *FrameScanner) Err() error {
+	return s.scanner.Err()
+}
@@ -0,0 +1,99 @@
+package mbus
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+	"testing/iotest"
+)
+
+func TestScanFrames(t *testing.T) {
+	short := []byte{0x10, 0x40, 0x01, 0x41, 0x16}
+	control := []byte{0x68, 0x03, 0x03, 0x68, 0x53, 0x01, 0xBD, 0x11, 0x16}
+	corrupt := append(append([]byte{}, testRspUd[:len(testRspUd)-2]...), 0x00, 0x16)
+
+	join := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+
+	tests := []struct {
+		name   string
+		stream []byte
+		want   [][]byte
+	}{
+		{
+			name:   "Concatenated frames",
+			stream: join(short, []byte{0xE5}, testRspUd, control),
+			want:   [][]byte{short, {0xE5}, testRspUd, control},
+		},
+		{
+			name:   "Noise between frames",
+			stream: join([]byte{0x00, 0x01}, short, []byte{0xFF, 0x68, 0x02}, testRspUd, []byte{0x42}),
+			want:   [][]byte{short, testRspUd},
+		},
+		{
+			name:   "Corrupt frame is skipped",
+			stream: join(corrupt, short),
+			want:   [][]byte{short},
+		},
+		{
+			name:   "Truncated frame at the end",
+			stream: join(short, testRspUd[:10]),
+			want:   [][]byte{short},
+		},
+		{
+			name:   "Only noise",
+			stream: []byte{0x00, 0x01, 0x02},
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// OneByteReader makes the scanner ask for more data in every state
+			s := bufio.NewScanner(iotest.OneByteReader(bytes.NewReader(tt.stream)))
+			s.Split(ScanFrames)
+			var got [][]byte
+			for s.Scan() {
+				got = append(got, append([]byte{}, s.Bytes()...))
+			}
+			if err := s.Err(); err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ScanFrames() got %d frames, want %d: % X", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if !bytes.Equal(got[i], tt.want[i]) {
+					t.Errorf("frame %d = % X, want % X", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFrameScanner(t *testing.T) {
+	stream := append([]byte{0x00, 0xE5}, testRspUd...)
+	s := NewFrameScanner(bytes.NewReader(stream))
+
+	var frames []Frame
+	for s.Scan() {
+		frames = append(frames, s.Frame())
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	if len(frames) != 2 {
+		t.Fatalf("got %d frames, want 2", len(frames))
+	}
+	if _, ok := frames[0].(*AckFrame); !ok {
+		t.Errorf("frame 0 is %T, want *AckFrame", frames[0])
+	}
+	lf, ok := frames[1].(*LFrame)
+	if !ok {
+		t.Fatalf("frame 1 is %T, want *LFrame", frames[1])
+	}
+	if !bytes.Equal(lf.Bytes(), testRspUd) {
+		t.Errorf("LFrame = % X, want % X", lf.Bytes(), testRspUd)
+	}
+}
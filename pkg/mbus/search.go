@@ -0,0 +1,180 @@
+package mbus
+
+import (
+	"context"
+	"encoding/binary"
+	"strings"
+)
+
+// SecondarySearch is the result of a secondary address search
+type SecondarySearch struct {
+	// Found are the secondary addresses of the slaves, as reported in their RSP_UD
+	Found []SecondaryAddress `yaml:"found" json:"found"`
+	// Collisions are the masks where several slaves still answer with identification
+	// number, version and medium fixed, i.e. slaves that differ only in the manufacturer
+	Collisions []SecondaryAddress `yaml:"collisions" json:"collisions"`
+}
+
+// SecondaryAddress returns the secondary address from the fixed data header
+func (lf *LFrame) SecondaryAddress() SecondaryAddress {
+	return SecondaryAddress{
+		IdentificationNumber: lf.IdentificationNumber(),
+		Manufacturer:         lf.Manufacturer(),
+		ManufacturerID:       binary.LittleEndian.Uint16(lf.data[11:13]),
+		Version:              byte(lf.Version()),
+		Medium:               lf.Medium(),
+	}
+}
+
+// SearchSecondary finds all slaves on the port by their secondary address.
+func SearchSecondary(port string) (SecondarySearch, error) {
+	return SearchSecondaryContext(context.Background(), port)
+}
+
+// SearchSecondaryWith finds all slaves by their secondary address using an already open transport.
+// See searchSecondary for the algorithm.
+func SearchSecondaryWith(t Transport) (SecondarySearch, error) {
+	return SearchSecondaryWithContext(context.Background(), t)
+}
+
+// SearchSecondaryContext is SearchSecondary that stops the search when the context is
+// done and closes the port immediately.
+func SearchSecondaryContext(ctx context.Context, port string) (SecondarySearch, error) {
+	t, err := openTransportWaitContext(ctx, port)
+	if err != nil {
+		return SecondarySearch{}, err
+	}
+	t, release := ownTransport(ctx, t)
+	defer release()
+
+	return SearchSecondaryWithContext(ctx, t)
+}
+
+// SearchSecondaryWithContext is SearchSecondaryWith that stops the search when the
+// context is done; the transport stays open.
+func SearchSecondaryWithContext(ctx context.Context, t Transport) (SecondarySearch, error) {
+	ct, release := withContext(ctx, t)
+	defer release()
+
+	return searchSecondary(ct, transportTiming(t))
+}
+
+// searchSecondary walks the digit tree of the identification number. It selects
+// "0FFFFFFF" ... "9FFFFFFF"; a mask without answer is skipped, a mask with one
+// slave is read at address 253 and a collision is split on the next digit.
+// A collision with all 8 digits fixed is split on the medium and the version.
+func searchSecondary(t Transport, timing Timing) (SecondarySearch, error) {
+	var result SecondarySearch
+	err := searchSecondaryDigit(t, "", timing, &result)
+	return result, err
+}
+
+func searchSecondaryDigit(t Transport, prefix string, timing Timing, result *SecondarySearch) error {
+	for digit := byte('0'); digit <= '9'; digit++ {
+		id := prefix + string(digit)
+		mask := SecondaryAddress{
+			IdentificationNumber: id + strings.Repeat("F", 8-len(id)),
+			Version:              AnyVersion,
+			Medium:               AnyMedium,
+		}
+
+		found, collision, err := probeSecondary(t, mask, timing)
+		if err != nil {
+			return err
+		}
+		switch {
+		case found != nil:
+			result.Found = append(result.Found, *found)
+		case collision && len(id) == 8:
+			if err := searchSecondaryHeader(t, mask, timing, result); err != nil {
+				return err
+			}
+		case collision:
+			if err := searchSecondaryDigit(t, id, timing, result); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// searchSecondaryHeader splits a collision of slaves with the same identification
+// number on each value of the medium byte, then of the version byte. The manufacturer
+// has no byte wildcard to walk, slaves that differ only there stay a collision.
+func searchSecondaryHeader(t Transport, mask SecondaryAddress, timing Timing, result *SecondarySearch) error {
+	var masks []SecondaryAddress
+	switch {
+	case mask.Medium == AnyMedium:
+		for medium := 0; medium < int(AnyMedium); medium++ {
+			next := mask
+			next.Medium = MediumType(medium)
+			masks = append(masks, next)
+		}
+	case mask.Version == AnyVersion:
+		for version := 0; version < int(AnyVersion); version++ {
+			next := mask
+			next.Version = byte(version)
+			masks = append(masks, next)
+		}
+	default:
+		result.Collisions = append(result.Collisions, mask)
+		return nil
+	}
+
+	for _, next := range masks {
+		found, collision, err := probeSecondary(t, next, timing)
+		if err != nil {
+			return err
+		}
+		switch {
+		case found != nil:
+			result.Found = append(result.Found, *found)
+		case collision:
+			if err := searchSecondaryHeader(t, next, timing, result); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// probeSecondary selects the mask. If exactly one slave answers, it returns its
+// secondary address. Several slaves are detected as a garbled answer to the
+// selection or to the following REQ_UD2.
+func probeSecondary(t Transport, mask SecondaryAddress, timing Timing) (*SecondaryAddress, bool, error) {
+	data, err := mask.Bytes()
+	if err != nil {
+		return nil, false, err
+	}
+	command, err := COMMAND_SND_UD(uint(AFieldNetworkLayerAddress), CiFieldSelectionOfSlaves, data)
+	if err != nil {
+		return nil, false, err
+	}
+
+	_, response, err := sendProbe(t, command, timing)
+	switch {
+	case response == ResponseCollision:
+		return nil, true, nil
+	case err != nil:
+		return nil, false, err
+	case response != ResponseAck:
+		return nil, false, nil
+	}
+
+	// The ACKs of several slaves can overlap into one 0xE5, only the RSP_UD tells them apart
+	rawData, response, err := sendRequest(t, COMMAND_REQ_UD2(uint(AFieldNetworkLayerAddress)), timing)
+	switch {
+	case response == ResponseCollision:
+		return nil, true, nil
+	case err != nil:
+		return nil, false, err
+	case response == ResponseNone:
+		return nil, false, nil
+	}
+	frame := NewLFrame(rawData)
+	if _, err := frame.Verify(); err != nil || len(rawData) < 21 {
+		return nil, true, nil
+	}
+	address := frame.SecondaryAddress()
+	return &address, false, nil
+}
@@ -0,0 +1,142 @@
+package mbus
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// testSlave builds the RSP_UD telegram of a slave with the secondary address
+func testSlave(t *testing.T, secondary string) []byte {
+	t.Helper()
+	address, err := ParseSecondaryAddress(secondary)
+	if err != nil {
+		t.Fatalf("ParseSecondaryAddress() error = %v", err)
+	}
+	header, err := address.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes() error = %v", err)
+	}
+	// access number, status, signature and one volume record
+	data := append(header, 0x55, 0x00, 0x00, 0x00, 0x0C, 0x13, 0x27, 0x04, 0x85, 0x02)
+	frame, err := EncodeLFrame(CFIELD_RSP_UD_a, NewAField(0), CiFieldVariable72, data)
+	if err != nil {
+		t.Fatalf("EncodeLFrame() error = %v", err)
+	}
+	return frame.Bytes()
+}
+
+// fakeSegment simulates several slaves at primary address 0 on one segment. Selected
+// slaves answer at the same time: their ACKs overlap into one 0xE5, their RSP_UD into garbage.
+func fakeSegment(t *testing.T, conn net.Conn, slaves [][]byte) {
+	var selected [][]byte
+	fakeSlave(t, conn, func(request []byte) []byte {
+		switch {
+		case len(request) == 17 && request[5] == 253 && request[6] == CiFieldSelectionOfSlaves:
+			selected = nil
+			for _, slave := range slaves {
+				if secondaryMatches(request[7:15], slave[7:15]) {
+					selected = append(selected, slave)
+				}
+			}
+			if len(selected) > 0 {
+				return []byte{FRAME_ACK_START}
+			}
+		case len(request) == 5 && request[2] == 253 && len(selected) == 1:
+			return selected[0]
+		case len(request) == 5 && request[2] == 253 && len(selected) > 1:
+			garbled := append([]byte{}, selected[0]...)
+			for i := range garbled[4 : len(garbled)-2] {
+				garbled[4+i] |= selected[1][4+i]
+			}
+			garbled[len(garbled)-2] ^= 0xFF
+			return garbled
+		}
+		return nil
+	})
+}
+
+func TestSearchSecondary(t *testing.T) {
+	slaves := [][]byte{
+		testSlave(t, "1234567840240107"),
+		testSlave(t, "1234567940240107"),
+		testSlave(t, "8765432140240102"),
+		testSlave(t, "1111111140240107"),
+		testSlave(t, "1111111140240102"),
+	}
+
+	tr, conn := NewPipeTransport()
+	defer tr.Close()
+	fakeSegment(t, conn, slaves)
+
+	timing := Timing{Response: 10 * time.Millisecond, InterCharacter: 5 * time.Millisecond}
+	result, err := searchSecondary(tr, timing)
+	if err != nil {
+		t.Fatalf("searchSecondary() error = %v", err)
+	}
+
+	// The slaves with identification number 11111111 are told apart by the medium
+	wantFound := []string{"1111111140240102", "1111111140240107", "1234567840240107", "1234567940240107", "8765432140240102"}
+	if len(result.Found) != len(wantFound) {
+		t.Fatalf("searchSecondary() found %v, want %v", result.Found, wantFound)
+	}
+	for i, want := range wantFound {
+		if result.Found[i].String() != want {
+			t.Errorf("Found[%d] = %s, want %s", i, result.Found[i], want)
+		}
+	}
+
+	if len(result.Collisions) != 0 {
+		t.Errorf("searchSecondary() collisions = %v, want none", result.Collisions)
+	}
+}
+
+func TestSearchSecondary_SameIdentificationNumber(t *testing.T) {
+	slaves := [][]byte{
+		testSlave(t, "2222222240240207"),
+		testSlave(t, "2222222240240107"),
+		testSlave(t, "333333332D2C0107"),
+		testSlave(t, "3333333340240107"),
+	}
+
+	tr, conn := NewPipeTransport()
+	defer tr.Close()
+	fakeSegment(t, conn, slaves)
+
+	timing := Timing{Response: 5 * time.Millisecond, InterCharacter: 5 * time.Millisecond}
+	result, err := searchSecondary(tr, timing)
+	if err != nil {
+		t.Fatalf("searchSecondary() error = %v", err)
+	}
+
+	// Same medium, split on the version
+	wantFound := []string{"2222222240240107", "2222222240240207"}
+	if len(result.Found) != len(wantFound) {
+		t.Fatalf("searchSecondary() found %v, want %v", result.Found, wantFound)
+	}
+	for i, want := range wantFound {
+		if result.Found[i].String() != want {
+			t.Errorf("Found[%d] = %s, want %s", i, result.Found[i], want)
+		}
+	}
+
+	// Only the manufacturer differs
+	if len(result.Collisions) != 1 || result.Collisions[0].String() != "33333333FFFF0107" {
+		t.Errorf("searchSecondary() collisions = %v, want [33333333FFFF0107]", result.Collisions)
+	}
+}
+
+func TestSearchSecondary_EmptyBus(t *testing.T) {
+	tr, conn := NewPipeTransport()
+	defer tr.Close()
+	fakeSegment(t, conn, nil)
+
+	timing := Timing{Response: 10 * time.Millisecond, InterCharacter: 5 * time.Millisecond}
+	result, err := searchSecondary(tr, timing)
+	if err != nil {
+		t.Fatalf("searchSecondary() error = %v", err)
+	}
+	if len(result.Found) != 0 || len(result.Collisions) != 0 {
+		t.Errorf("searchSecondary() = %+v, want nothing", result)
+	}
+}
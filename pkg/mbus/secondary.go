@@ -0,0 +1,157 @@
+package mbus
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Wildcards of the secondary address fields
+const (
+	AnyVersion byte       = 0xFF
+	AnyMedium  MediumType = 0xFF
+)
+
+// ErrInvalidSecondaryAddress is returned for a malformed secondary address
+var ErrInvalidSecondaryAddress = errors.New("invalid secondary address")
+
+// SecondaryAddress identifies a slave by the fixed data header of its RSP_UD telegram,
+// independent of its primary address. The master selects the slave with SND_UD
+// CI 0x52 and then talks to it at the network layer address 253.
+type SecondaryAddress struct {
+	// IdentificationNumber has 8 digits, F is a wildcard for one digit
+	IdentificationNumber string `yaml:"identification_number" json:"identification_number"`
+	// Manufacturer is the 3 letter code, empty for any manufacturer
+	Manufacturer string `yaml:"manufacturer" json:"manufacturer"`
+	// ManufacturerID is the raw 2 byte manufacturer field, 0 if only Manufacturer is set.
+	// It is used instead of Manufacturer, which cannot hold every field value.
+	ManufacturerID uint16 `yaml:"manufacturer_id,omitempty" json:"manufacturer_id,omitempty"`
+	// Version is the version of the slave, AnyVersion for any
+	Version byte `yaml:"version" json:"version"`
+	// Medium is the medium of the slave, AnyMedium for any
+	Medium MediumType `yaml:"medium" json:"medium"`
+}
+
+// ParseSecondaryAddress parses the 16 HEX digit form used by most M-Bus tools:
+// 8 digits identification number, 4 digits manufacturer, 2 digits version and
+// 2 digits medium, e.g. "12345678FFFFFFFF" or "1234567840240107".
+// F is a wildcard in every position.
+func ParseSecondaryAddress(s string) (SecondaryAddress, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if len(s) != 16 {
+		return SecondaryAddress{}, fmt.Errorf("%w: %q must have 16 HEX digits", ErrInvalidSecondaryAddress, s)
+	}
+	raw, err := hex.DecodeString(s[8:])
+	if err != nil {
+		return SecondaryAddress{}, fmt.Errorf("%w: %q is not HEX", ErrInvalidSecondaryAddress, s)
+	}
+
+	address := SecondaryAddress{
+		IdentificationNumber: s[:8],
+		Version:              raw[2],
+		Medium:               MediumType(raw[3]),
+	}
+	if raw[0] != 0xFF || raw[1] != 0xFF {
+		// Manufacturer is written big endian in the HEX form
+		address.Manufacturer = DecodeManufacturerId([]byte{raw[1], raw[0]})
+		address.ManufacturerID = uint16(raw[0])<<8 | uint16(raw[1])
+	}
+	if _, err := address.Bytes(); err != nil {
+		return SecondaryAddress{}, err
+	}
+	return address, nil
+}
+
+// String returns the 16 HEX digit form of the secondary address
+func (sa SecondaryAddress) String() string {
+	manufacturer := "FFFF"
+	if m, err := sa.manufacturerBytes(); err == nil {
+		manufacturer = fmt.Sprintf("%02X%02X", m[1], m[0])
+	}
+	return fmt.Sprintf("%s%s%02X%02X", strings.ToUpper(sa.IdentificationNumber), manufacturer, sa.Version, byte(sa.Medium))
+}
+
+// Bytes returns the 8 bytes of the selection telegram: identification number
+// BCD little endian, manufacturer, version and medium
+func (sa SecondaryAddress) Bytes() ([]byte, error) {
+	id := strings.ToUpper(sa.IdentificationNumber)
+	if len(id) != 8 {
+		return nil, fmt.Errorf("%w: identification number %q must have 8 digits", ErrInvalidSecondaryAddress, sa.IdentificationNumber)
+	}
+	b := make([]byte, 0, 8)
+	for i := 6; i >= 0; i -= 2 {
+		high, okHigh := secondaryNibble(id[i])
+		low, okLow := secondaryNibble(id[i+1])
+		if !okHigh || !okLow {
+			return nil, fmt.Errorf("%w: identification number %q must be digits or F", ErrInvalidSecondaryAddress, sa.IdentificationNumber)
+		}
+		b = append(b, high<<4|low)
+	}
+
+	m, err := sa.manufacturerBytes()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSecondaryAddress, err)
+	}
+	b = append(b, m...)
+
+	return append(b, sa.Version, byte(sa.Medium)), nil
+}
+
+// manufacturerBytes returns the manufacturer field little endian, from ManufacturerID
+// if set and encoded from Manufacturer otherwise
+func (sa SecondaryAddress) manufacturerBytes() ([]byte, error) {
+	switch {
+	case sa.ManufacturerID != 0:
+		return []byte{byte(sa.ManufacturerID), byte(sa.ManufacturerID >> 8)}, nil
+	case sa.Manufacturer == "":
+		return []byte{0xFF, 0xFF}, nil
+	}
+	return EncodeManufacturerId(sa.Manufacturer)
+}
+
+// HasWildcard returns true if the address can match more than one slave
+func (sa SecondaryAddress) HasWildcard() bool {
+	return strings.ContainsAny(sa.IdentificationNumber, "Ff") ||
+		sa.ManufacturerID == 0xFFFF ||
+		sa.ManufacturerID == 0 && sa.Manufacturer == "" ||
+		sa.Version == AnyVersion ||
+		sa.Medium == AnyMedium
+}
+
+func secondaryNibble(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c == 'F':
+		return 0x0F, true
+	}
+	return 0, false
+}
+
+// ErrNotSelected is returned when no slave acknowledges the selection of the secondary address
+var ErrNotSelected = errors.New("no slave selected by secondary address")
+
+// selectSecondary selects the slave with SND_UD CI 0x52 at address 253. Every slave that
+// matches the address answers with an ACK.
+func selectSecondary(t Transport, address SecondaryAddress, timing Timing) error {
+	data, err := address.Bytes()
+	if err != nil {
+		return err
+	}
+	err = sendUserData(t, uint(AFieldNetworkLayerAddress), CiFieldSelectionOfSlaves, data, timing)
+	if errors.Is(err, ErrNoAck) {
+		return fmt.Errorf("%w: %s", ErrNotSelected, address)
+	}
+	return err
+}
+
+// readSecondaryDeviceState selects the slave by secondary address and reads it at address 253.
+// The readout starts with FCB 0, address 253 is shared by all slaves.
+func readSecondaryDeviceState(t Transport, address SecondaryAddress, timing Timing) (LFrameParsed, error) {
+	if err := selectSecondary(t, address, timing); err != nil {
+		return LFrameParsed{}, err
+	}
+	data, _, err := readDeviceState(t, uint(AFieldNetworkLayerAddress), false, timing)
+	return data, err
+}
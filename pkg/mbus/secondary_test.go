@@ -0,0 +1,162 @@
+package mbus
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestParseSecondaryAddress(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      SecondaryAddress
+		wantBytes []byte
+		wantErr   error
+	}{
+		{
+			name:      "Full address",
+			input:     "1234567840240107",
+			want:      SecondaryAddress{IdentificationNumber: "12345678", Manufacturer: "PAD", ManufacturerID: 0x4024, Version: 0x01, Medium: 0x07},
+			wantBytes: []byte{0x78, 0x56, 0x34, 0x12, 0x24, 0x40, 0x01, 0x07},
+		},
+		{
+			name:      "Wildcards",
+			input:     "1234fFFFffffffff",
+			want:      SecondaryAddress{IdentificationNumber: "1234FFFF", Version: AnyVersion, Medium: AnyMedium},
+			wantBytes: []byte{0xFF, 0xFF, 0x34, 0x12, 0xFF, 0xFF, 0xFF, 0xFF},
+		},
+		{
+			name:      "Manufacturer outside A-Z",
+			input:     "123456786C210107",
+			want:      SecondaryAddress{IdentificationNumber: "12345678", Manufacturer: "[AA", ManufacturerID: 0x6C21, Version: 0x01, Medium: 0x07},
+			wantBytes: []byte{0x78, 0x56, 0x34, 0x12, 0x21, 0x6C, 0x01, 0x07},
+		},
+		{
+			name:      "Manufacturer with bit 15 set",
+			input:     "12345678C0240107",
+			want:      SecondaryAddress{IdentificationNumber: "12345678", Manufacturer: "PAD", ManufacturerID: 0xC024, Version: 0x01, Medium: 0x07},
+			wantBytes: []byte{0x78, 0x56, 0x34, 0x12, 0x24, 0xC0, 0x01, 0x07},
+		},
+		{name: "Too short", input: "12345678", wantErr: ErrInvalidSecondaryAddress},
+		{name: "Letter in identification number", input: "1234567A40240107", wantErr: ErrInvalidSecondaryAddress},
+		{name: "Not HEX", input: "123456784024010X", wantErr: ErrInvalidSecondaryAddress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSecondaryAddress(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseSecondaryAddress() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseSecondaryAddress() = %+v, want %+v", got, tt.want)
+			}
+			gotBytes, err := got.Bytes()
+			if err != nil {
+				t.Fatalf("Bytes() error = %v", err)
+			}
+			if !bytes.Equal(gotBytes, tt.wantBytes) {
+				t.Errorf("Bytes() = % X, want % X", gotBytes, tt.wantBytes)
+			}
+			if got.String() != strings.ToUpper(tt.input) {
+				t.Errorf("String() = %v, want %v", got.String(), strings.ToUpper(tt.input))
+			}
+		})
+	}
+}
+
+// secondarySlave answers like the slave of testRspUd: it acknowledges the selection
+// of a matching secondary address and answers REQ_UD2 at address 253 while selected
+func secondarySlave(t *testing.T, conn net.Conn) {
+	own := testRspUd[7:15]
+	selected := false
+	fakeSlave(t, conn, func(request []byte) []byte {
+		switch {
+		case len(request) == 17 && request[5] == 253 && request[6] == CiFieldSelectionOfSlaves:
+			selected = secondaryMatches(request[7:15], own)
+			if selected {
+				return []byte{FRAME_ACK_START}
+			}
+		case len(request) == 5 && request[2] == 253 && selected:
+			return testRspUd
+		}
+		return nil
+	})
+}
+
+// secondaryMatches compares the selection with the header. In the identification number
+// an F nibble matches any nibble, manufacturer, version and medium match any only as FF.
+func secondaryMatches(selection []byte, header []byte) bool {
+	if (selection[4] != 0xFF || selection[5] != 0xFF) && (selection[4] != header[4] || selection[5] != header[5]) {
+		return false
+	}
+	for i := 6; i < 8; i++ {
+		if selection[i] != 0xFF && selection[i] != header[i] {
+			return false
+		}
+	}
+	for i := 0; i < 4; i++ {
+		for _, mask := range []byte{0xF0, 0x0F} {
+			if selection[i]&mask != mask && selection[i]&mask != header[i]&mask {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestReadSecondaryWith(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		wantErr bool
+	}{
+		{name: "Full address", address: "1234567840240107"},
+		{name: "Wildcard", address: "1234FFFFFFFFFFFF"},
+		{name: "Other slave", address: "8765432140240107", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, slave := NewPipeTransport()
+			defer tr.Close()
+			secondarySlave(t, slave)
+
+			address, err := ParseSecondaryAddress(tt.address)
+			if err != nil {
+				t.Fatalf("ParseSecondaryAddress() error = %v", err)
+			}
+			ds := ReadSecondaryWith(tr, address)
+			if (ds.Error != "") != tt.wantErr {
+				t.Fatalf("ReadSecondaryWith() error = %q, wantErr %v", ds.Error, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if ds.Address != 253 {
+				t.Errorf("ReadSecondaryWith() Address = %v, want 253", ds.Address)
+			}
+			if ds.Data.IdentificationNumber != "12345678" {
+				t.Errorf("ReadSecondaryWith() IdentificationNumber = %v, want 12345678", ds.Data.IdentificationNumber)
+			}
+		})
+	}
+}
+
+func TestBus_ReadSecondary_NotSelected(t *testing.T) {
+	tr, slave := NewPipeTransport()
+	secondarySlave(t, slave)
+	bus := NewBus(tr)
+	defer bus.Close()
+
+	address := SecondaryAddress{IdentificationNumber: "87654321", Manufacturer: "PAD", Version: 1, Medium: 7}
+	if _, err := bus.ReadSecondary(address); !errors.Is(err, ErrNotSelected) {
+		t.Errorf("ReadSecondary() error = %v, want %v", err, ErrNotSelected)
+	}
+}
@@ -0,0 +1,124 @@
+package mbus
+
+import (
+	"errors"
+	"fmt"
+)
+
+// difSelectionForReadout is the data field code 0b1000 "selection for readout" of the DIF
+const difSelectionForReadout = 0x08
+
+// maxDIFE is the number of DIFE a data record may have
+const maxDIFE = 10
+
+// ErrInvalidSelector is returned for a record selector that can not be encoded
+var ErrInvalidSelector = errors.New("invalid record selector")
+
+// RecordSelector selects one data record for readout, e.g. current energy of tariff 0.
+// It is sent as DIF with data field "selection for readout", DIFE and VIF/VIFE, without data.
+type RecordSelector struct {
+	// Function instantaneous, maximum, minimum or value during error (DIF bits 5-6)
+	Function DIFFieldTypeOfData
+	// StorageNumber 0 is the current value, higher numbers are historic values
+	StorageNumber uint
+	Tariff        uint
+	SubUnit       uint
+	// VIF without extension bit, it is set if VIFE follow
+	VIF byte
+	// VIFE without extension bits, they are set on all but the last
+	VIFE []byte
+}
+
+// Bytes encodes the selector as data record header
+func (rs RecordSelector) Bytes() ([]byte, error) {
+	if rs.Function > VALUE_DURING_ERROR {
+		return nil, fmt.Errorf("%w: function %d", ErrInvalidSelector, rs.Function)
+	}
+
+	dif := byte(difSelectionForReadout) | byte(rs.Function)<<4
+	header, err := encodeRecordHeader(dif, rs.StorageNumber, rs.Tariff, rs.SubUnit, rs.VIF, rs.VIFE)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSelector, err)
+	}
+	return header, nil
+}
+
+// encodeRecordHeader encodes DIF, DIFE, VIF and VIFE of a data record. dif holds the
+// data field and function, the lowest bit of the storage number and the extension
+// bits are added. The caller wraps the error with its own sentinel.
+func encodeRecordHeader(dif byte, storageNumber uint, tariff uint, subUnit uint, vif byte, vifes []byte) ([]byte, error) {
+	dif |= byte(storageNumber&0x01) << 6
+	storage := storageNumber >> 1
+	var difes []byte
+	for storage != 0 || tariff != 0 || subUnit != 0 {
+		if len(difes) == maxDIFE {
+			return nil, errors.New("storage number, tariff or subunit too large")
+		}
+		difes = append(difes, byte(storage&0x0F)|byte(tariff&0x03)<<4|byte(subUnit&0x01)<<6)
+		storage, tariff, subUnit = storage>>4, tariff>>2, subUnit>>1
+	}
+
+	data := []byte{dif}
+	if len(difes) > 0 {
+		data[0] |= 0x80
+	}
+	for i, dife := range difes {
+		if i < len(difes)-1 {
+			dife |= 0x80
+		}
+		data = append(data, dife)
+	}
+
+	vif &= 0x7F
+	if len(vifes) > 0 {
+		vif |= 0x80
+	}
+	data = append(data, vif)
+	for i, vife := range vifes {
+		vife &= 0x7F
+		if i < len(vifes)-1 {
+			vife |= 0x80
+		}
+		data = append(data, vife)
+	}
+	return data, nil
+}
+
+// COMMAND_SELECT_RECORDS Send the record selectors to the slave with SND_UD CI 0x51.
+// A slave that supports selection returns only these records on the next REQ_UD2.
+func COMMAND_SELECT_RECORDS(deviceAddress uint, selectors []RecordSelector) ([]byte, error) {
+	data, err := selectorData(selectors)
+	if err != nil {
+		return nil, err
+	}
+	return COMMAND_SND_UD(deviceAddress, CiFieldDataSend, data)
+}
+
+// selectorData returns the records of the selectors one after the other
+func selectorData(selectors []RecordSelector) ([]byte, error) {
+	if len(selectors) == 0 {
+		return nil, fmt.Errorf("%w: no selector", ErrInvalidSelector)
+	}
+	var data []byte
+	for _, selector := range selectors {
+		record, err := selector.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, record...)
+	}
+	return data, nil
+}
+
+// readSelected sends the record selectors, waits for the ACK and reads class 2 data
+// starting with the frame count bit fcb. It returns the FCB for the next request.
+func readSelected(t Transport, deviceAddress uint, selectors []RecordSelector, fcb bool, timing Timing) (LFrameParsed, bool, error) {
+	data, err := selectorData(selectors)
+	if err != nil {
+		return LFrameParsed{}, fcb, err
+	}
+	if err := sendUserData(t, deviceAddress, CiFieldDataSend, data, timing); err != nil {
+		return LFrameParsed{}, fcb, err
+	}
+	return readDeviceState(t, deviceAddress, fcb, timing)
+}
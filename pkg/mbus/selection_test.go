@@ -0,0 +1,70 @@
+package mbus
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestRecordSelector_Bytes(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector RecordSelector
+		want     []byte
+		wantErr  error
+	}{
+		{name: "Current energy", selector: RecordSelector{VIF: 0x06}, want: []byte{0x08, 0x06}},
+		{name: "Tariff 1", selector: RecordSelector{VIF: 0x06, Tariff: 1}, want: []byte{0x88, 0x10, 0x06}},
+		{name: "Storage 1", selector: RecordSelector{VIF: 0x13, StorageNumber: 1}, want: []byte{0x48, 0x13}},
+		{name: "Storage 2 and subunit 1", selector: RecordSelector{VIF: 0x13, StorageNumber: 2, SubUnit: 1}, want: []byte{0x88, 0x41, 0x13}},
+		{name: "Storage 40", selector: RecordSelector{VIF: 0x13, StorageNumber: 40}, want: []byte{0x88, 0x84, 0x01, 0x13}},
+		{name: "Maximum power", selector: RecordSelector{Function: MAXIMUM, VIF: 0x2B}, want: []byte{0x18, 0x2B}},
+		{name: "VIF extension", selector: RecordSelector{VIF: 0x7D, VIFE: []byte{0x17}}, want: []byte{0x08, 0xFD, 0x17}},
+		{name: "Invalid function", selector: RecordSelector{Function: 4, VIF: 0x06}, wantErr: ErrInvalidSelector},
+		{name: "Storage number too large", selector: RecordSelector{VIF: 0x13, StorageNumber: 1 << 42}, wantErr: ErrInvalidSelector},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.selector.Bytes()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Bytes() error = %v, want %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("Bytes() = % X, want % X", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBus_ReadSelected(t *testing.T) {
+	tr, slave := NewPipeTransport()
+	var selection []byte
+	fakeSlave(t, slave, func(request []byte) []byte {
+		switch {
+		case request[0] == FRAME_LONG_START && request[5] == 1 && CIField(request[6]) == CiFieldDataSend:
+			selection = append([]byte{}, request[7:len(request)-2]...)
+			return []byte{FRAME_ACK_START}
+		case len(request) == 5 && request[2] == 1 && selection != nil:
+			return testRspUd
+		}
+		return nil
+	})
+	bus := NewBus(tr)
+	defer bus.Close()
+
+	data, err := bus.ReadSelected(1, []RecordSelector{{VIF: 0x13}, {VIF: 0x06, Tariff: 1}})
+	if err != nil {
+		t.Fatalf("ReadSelected() error = %v", err)
+	}
+	if want := []byte{0x08, 0x13, 0x88, 0x10, 0x06}; !bytes.Equal(selection, want) {
+		t.Errorf("slave received selection % X, want % X", selection, want)
+	}
+	if len(data.Records) != 1 {
+		t.Errorf("ReadSelected() returned %d records, want 1", len(data.Records))
+	}
+
+	if _, err := bus.ReadSelected(1, nil); !errors.Is(err, ErrInvalidSelector) {
+		t.Errorf("ReadSelected() without selectors error = %v, want %v", err, ErrInvalidSelector)
+	}
+}
@@ -0,0 +1,75 @@
+package mbus
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// Transport is a byte link to an M-Bus segment: a local serial port,
+// a network gateway or an in-memory pipe used in tests.
+//
+// Read must return an error satisfying errors.Is(err, os.ErrDeadlineExceeded)
+// once the deadline set by SetReadDeadline has passed. A zero deadline means
+// Read does not time out.
+type Transport interface {
+	// Write sends one complete frame to the bus.
+	Write(frame []byte) (int, error)
+	// Read reads the bytes received so far, waiting until the read deadline.
+	Read(p []byte) (int, error)
+	// SetReadDeadline sets the deadline for future Read calls.
+	SetReadDeadline(t time.Time) error
+	// Close releases the underlying port or connection.
+	Close() error
+}
+
+// BaudRateSetter is implemented by transports whose baud rate can be changed
+// while they are open.
+type BaudRateSetter interface {
+	SetBaudRate(baud int) error
+}
+
+// BaudRateGetter is implemented by transports that know the baud rate they are set to.
+type BaudRateGetter interface {
+	BaudRate() (int, error)
+}
+
+// OpenTransport opens the transport for the given port string.
+// Device paths like /dev/ttyUSB0 or COM3 are opened as serial ports with the
+// M-Bus default settings 2400 8E1. Ports like tcp://10.0.0.5:10001 connect to
+// an Ethernet-to-M-Bus level converter and rfc2217://10.0.0.5:2217 to a serial
+// server speaking RFC 2217.
+func OpenTransport(port string) (Transport, error) {
+	if addr, ok := strings.CutPrefix(port, "tcp://"); ok {
+		return DialTCPTransport(addr, DefaultDialTimeout)
+	}
+	if addr, ok := strings.CutPrefix(port, "rfc2217://"); ok {
+		return DialRFC2217Transport(addr, defaultBaud, DefaultDialTimeout)
+	}
+	return OpenSerialTransport(port, defaultBaud)
+}
+
+// NewPipeTransport returns an in-memory transport. The returned net.Conn is
+// the slave side of the pipe: everything written to the transport can be read
+// from it and everything written to it is received by the transport.
+func NewPipeTransport() (Transport, net.Conn) {
+	master, slave := net.Pipe()
+	return master, slave
+}
+
+// isTimeout returns true if err is a read deadline error of a transport
+func isTimeout(err error) bool {
+	// A context that is done interrupts the read, see contextTransport. Its error
+	// looks like a timeout but must not be taken as "no response".
+	if errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
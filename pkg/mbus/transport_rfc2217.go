@@ -0,0 +1,341 @@
+package mbus
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// Telnet (RFC 854) command bytes
+const (
+	telnetSE   byte = 240
+	telnetSB   byte = 250
+	telnetWILL byte = 251
+	telnetWONT byte = 252
+	telnetDO   byte = 253
+	telnetDONT byte = 254
+	telnetIAC  byte = 255
+)
+
+// Telnet options
+const (
+	telnetOptionBinary          byte = 0
+	telnetOptionSuppressGoAhead byte = 3
+	telnetOptionComPort         byte = 44
+)
+
+// RFC 2217 COM-PORT-OPTION client commands. The server answers with the
+// command + 100.
+const (
+	comPortSetBaudrate byte = 1
+	comPortSetDatasize byte = 2
+	comPortSetParity   byte = 3
+	comPortSetStopsize byte = 4
+	comPortServerReply byte = 100
+)
+
+// RFC 2217 parity and stop size values
+const (
+	comPortParityEven byte = 3
+	comPortStopsize1  byte = 1
+)
+
+// DefaultNegotiationTimeout is how long RFC2217Transport waits for the server
+// to acknowledge an option or a COM port setting.
+const DefaultNegotiationTimeout = 3 * time.Second
+
+// telnet receive parser states
+const (
+	telnetStateData = iota
+	telnetStateIAC
+	telnetStateOption
+	telnetStateSB
+	telnetStateSBIAC
+)
+
+// RFC2217Transport is a Transport to a serial server speaking RFC 2217
+// (Telnet COM Port Control). Baud rate, data size, parity and stop bits are
+// negotiated with the server, so the remote port runs 8E1 as M-Bus requires.
+type RFC2217Transport struct {
+	conn    net.Conn
+	timeout time.Duration
+
+	writeMu sync.Mutex
+
+	// receive side, only used by the reader
+	state   int
+	command byte
+	sb      []byte
+	pending []byte
+
+	// option negotiation
+	mu       sync.Mutex
+	comPort  bool
+	settings map[byte][]byte
+	deadline time.Time
+}
+
+// DialRFC2217Transport connects to the serial server at addr (host:port) and
+// configures the remote port to baud 8E1.
+func DialRFC2217Transport(addr string, baud int, dialTimeout time.Duration) (*RFC2217Transport, error) {
+	conn, err := net.DialTimeout("tcp", addr, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	rt := &RFC2217Transport{
+		conn:     conn,
+		timeout:  DefaultNegotiationTimeout,
+		settings: make(map[byte][]byte),
+	}
+	if err := rt.negotiate(baud); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return rt, nil
+}
+
+func (rt *RFC2217Transport) negotiate(baud int) error {
+	err := rt.writeRaw([]byte{
+		telnetIAC, telnetWILL, telnetOptionComPort,
+		telnetIAC, telnetWILL, telnetOptionBinary,
+		telnetIAC, telnetDO, telnetOptionBinary,
+	})
+	if err != nil {
+		return err
+	}
+	err = rt.waitFor(func() bool { return rt.comPort })
+	if err != nil {
+		return fmt.Errorf("rfc2217: server does not support COM port control: %w", err)
+	}
+	if err := rt.SetBaudRate(baud); err != nil {
+		return err
+	}
+	if err := rt.setComPort(comPortSetDatasize, []byte{8}); err != nil {
+		return err
+	}
+	if err := rt.setComPort(comPortSetParity, []byte{comPortParityEven}); err != nil {
+		return err
+	}
+	return rt.setComPort(comPortSetStopsize, []byte{comPortStopsize1})
+}
+
+// SetBaudRate changes the baud rate of the remote port and waits until the
+// server acknowledges it. A server that answers with another rate than the
+// requested one does not support it.
+func (rt *RFC2217Transport) SetBaudRate(baud int) error {
+	value := make([]byte, 4)
+	binary.BigEndian.PutUint32(value, uint32(baud))
+	if err := rt.setComPort(comPortSetBaudrate, value); err != nil {
+		return err
+	}
+	got, err := rt.BaudRate()
+	if err != nil {
+		return err
+	}
+	if got != baud {
+		return fmt.Errorf("rfc2217: server set %d baud instead of %d: %w", got, baud, ErrUnsupportedBaudRate)
+	}
+	return nil
+}
+
+// setComPort sends a COM-PORT-OPTION command and waits for the server reply
+func (rt *RFC2217Transport) setComPort(command byte, value []byte) error {
+	rt.mu.Lock()
+	delete(rt.settings, command+comPortServerReply)
+	rt.mu.Unlock()
+
+	b := []byte{telnetIAC, telnetSB, telnetOptionComPort, command}
+	b = append(b, escapeIAC(value)...)
+	b = append(b, telnetIAC, telnetSE)
+	if err := rt.writeRaw(b); err != nil {
+		return err
+	}
+
+	err := rt.waitFor(func() bool {
+		_, ok := rt.settings[command+comPortServerReply]
+		return ok
+	})
+	if err != nil {
+		return fmt.Errorf("rfc2217: no reply to COM port command %d: %w", command, err)
+	}
+	return nil
+}
+
+// waitFor reads from the server until done returns true or the negotiation
+// timeout passes. User data received meanwhile is kept for Read.
+func (rt *RFC2217Transport) waitFor(done func() bool) error {
+	if err := rt.conn.SetReadDeadline(time.Now().Add(rt.timeout)); err != nil {
+		return err
+	}
+	defer func() {
+		rt.mu.Lock()
+		_ = rt.conn.SetReadDeadline(rt.deadline)
+		rt.mu.Unlock()
+	}()
+
+	buf := make([]byte, rtuMaxSize)
+	for {
+		rt.mu.Lock()
+		ok := done()
+		rt.mu.Unlock()
+		if ok {
+			return nil
+		}
+		n, err := rt.conn.Read(buf)
+		if err != nil {
+			return err
+		}
+		rt.pending = append(rt.pending, rt.receive(buf[:n])...)
+	}
+}
+
+// receive strips telnet commands from the received bytes and returns user data
+func (rt *RFC2217Transport) receive(raw []byte) []byte {
+	var data []byte
+	for _, b := range raw {
+		switch rt.state {
+		case telnetStateData:
+			if b == telnetIAC {
+				rt.state = telnetStateIAC
+			} else {
+				data = append(data, b)
+			}
+		case telnetStateIAC:
+			switch b {
+			case telnetIAC:
+				data = append(data, telnetIAC)
+				rt.state = telnetStateData
+			case telnetWILL, telnetWONT, telnetDO, telnetDONT:
+				rt.command = b
+				rt.state = telnetStateOption
+			case telnetSB:
+				rt.sb = rt.sb[:0]
+				rt.state = telnetStateSB
+			default:
+				// NOP, GA and other commands without option
+				rt.state = telnetStateData
+			}
+		case telnetStateOption:
+			rt.option(rt.command, b)
+			rt.state = telnetStateData
+		case telnetStateSB:
+			if b == telnetIAC {
+				rt.state = telnetStateSBIAC
+			} else {
+				rt.sb = append(rt.sb, b)
+			}
+		case telnetStateSBIAC:
+			if b == telnetSE {
+				rt.subnegotiation(rt.sb)
+				rt.state = telnetStateData
+			} else {
+				rt.sb = append(rt.sb, b)
+				rt.state = telnetStateSB
+			}
+		}
+	}
+	return data
+}
+
+// option answers an option request of the server
+func (rt *RFC2217Transport) option(command byte, option byte) {
+	switch command {
+	case telnetDO:
+		switch option {
+		case telnetOptionComPort:
+			rt.mu.Lock()
+			rt.comPort = true
+			rt.mu.Unlock()
+		case telnetOptionBinary:
+		default:
+			_ = rt.writeRaw([]byte{telnetIAC, telnetWONT, option})
+		}
+	case telnetWILL:
+		switch option {
+		case telnetOptionBinary, telnetOptionSuppressGoAhead:
+		default:
+			_ = rt.writeRaw([]byte{telnetIAC, telnetDONT, option})
+		}
+	}
+}
+
+// subnegotiation records COM port replies of the server
+func (rt *RFC2217Transport) subnegotiation(sb []byte) {
+	if len(sb) < 2 || sb[0] != telnetOptionComPort {
+		return
+	}
+	rt.mu.Lock()
+	rt.settings[sb[1]] = append([]byte{}, sb[2:]...)
+	rt.mu.Unlock()
+}
+
+func (rt *RFC2217Transport) writeRaw(b []byte) error {
+	rt.writeMu.Lock()
+	defer rt.writeMu.Unlock()
+	_, err := rt.conn.Write(b)
+	return err
+}
+
+// Write sends the frame, doubling any 0xFF byte as telnet requires.
+func (rt *RFC2217Transport) Write(frame []byte) (int, error) {
+	if err := rt.writeRaw(escapeIAC(frame)); err != nil {
+		return 0, err
+	}
+	return len(frame), nil
+}
+
+// Read returns user data from the server until the read deadline.
+func (rt *RFC2217Transport) Read(p []byte) (int, error) {
+	buf := make([]byte, len(p))
+	for len(rt.pending) == 0 {
+		n, err := rt.conn.Read(buf)
+		if n > 0 {
+			rt.pending = append(rt.pending, rt.receive(buf[:n])...)
+		}
+		if err != nil && len(rt.pending) == 0 {
+			return 0, err
+		}
+	}
+	n := copy(p, rt.pending)
+	rt.pending = rt.pending[n:]
+	return n, nil
+}
+
+// SetReadDeadline sets the deadline for future Read calls.
+func (rt *RFC2217Transport) SetReadDeadline(t time.Time) error {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.deadline = t
+	return rt.conn.SetReadDeadline(t)
+}
+
+// Close closes the connection to the serial server.
+func (rt *RFC2217Transport) Close() error {
+	return rt.conn.Close()
+}
+
+// BaudRate returns the baud rate last acknowledged by the server
+func (rt *RFC2217Transport) BaudRate() (int, error) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	value, ok := rt.settings[comPortSetBaudrate+comPortServerReply]
+	if !ok || len(value) != 4 {
+		return 0, errors.New("rfc2217: baud rate not acknowledged by server")
+	}
+	return int(binary.BigEndian.Uint32(value)), nil
+}
+
+// escapeIAC doubles every 0xFF byte
+func escapeIAC(b []byte) []byte {
+	escaped := make([]byte, 0, len(b))
+	for _, c := range b {
+		escaped = append(escaped, c)
+		if c == telnetIAC {
+			escaped = append(escaped, telnetIAC)
+		}
+	}
+	return escaped
+}
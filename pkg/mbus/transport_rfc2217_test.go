@@ -0,0 +1,229 @@
+package mbus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRFC2217Server is a serial server accepting COM port settings and
+// echoing all user data back to the client.
+type fakeRFC2217Server struct {
+	ln net.Listener
+
+	mu       sync.Mutex
+	settings map[byte][]byte
+	// maxBaud is the highest baud rate the server accepts, higher rates are
+	// answered with maxBaud. 0 accepts every rate.
+	maxBaud uint32
+}
+
+func newFakeRFC2217Server(t *testing.T) *fakeRFC2217Server {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	s := &fakeRFC2217Server{ln: ln, settings: make(map[byte][]byte)}
+	go s.serve()
+	return s
+}
+
+func (s *fakeRFC2217Server) setting(command byte) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.settings[command]
+}
+
+func (s *fakeRFC2217Server) serve() {
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// Unsolicited requests the client has to refuse or ignore
+	_, _ = conn.Write([]byte{telnetIAC, telnetWILL, telnetOptionSuppressGoAhead, telnetIAC, telnetDO, 1})
+
+	var in []byte
+	buf := make([]byte, 256)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return
+		}
+		in = append(in, buf[:n]...)
+
+		var data []byte
+		for {
+			consumed := s.parse(conn, in, &data)
+			if consumed == 0 {
+				break
+			}
+			in = in[consumed:]
+		}
+		if len(data) > 0 {
+			_, _ = conn.Write(escapeIAC(data))
+		}
+	}
+}
+
+// parse handles one telnet element at the start of in and returns the number
+// of bytes consumed, 0 if the element is incomplete.
+func (s *fakeRFC2217Server) parse(conn net.Conn, in []byte, data *[]byte) int {
+	if len(in) == 0 {
+		return 0
+	}
+	if in[0] != telnetIAC {
+		*data = append(*data, in[0])
+		return 1
+	}
+	if len(in) < 2 {
+		return 0
+	}
+	switch in[1] {
+	case telnetIAC:
+		*data = append(*data, telnetIAC)
+		return 2
+	case telnetWILL, telnetWONT, telnetDO, telnetDONT:
+		if len(in) < 3 {
+			return 0
+		}
+		if in[1] == telnetWILL && in[2] == telnetOptionComPort {
+			_, _ = conn.Write([]byte{telnetIAC, telnetDO, telnetOptionComPort})
+		}
+		return 3
+	case telnetSB:
+		end := bytes.Index(in, []byte{telnetIAC, telnetSE})
+		if end < 0 {
+			return 0
+		}
+		sb := bytes.ReplaceAll(in[2:end], []byte{telnetIAC, telnetIAC}, []byte{telnetIAC})
+		if len(sb) >= 2 && sb[0] == telnetOptionComPort {
+			value := append([]byte{}, sb[2:]...)
+			s.mu.Lock()
+			if sb[1] == comPortSetBaudrate && len(value) == 4 && s.maxBaud > 0 && binary.BigEndian.Uint32(value) > s.maxBaud {
+				binary.BigEndian.PutUint32(value, s.maxBaud)
+			}
+			s.settings[sb[1]] = value
+			s.mu.Unlock()
+			reply := []byte{telnetIAC, telnetSB, telnetOptionComPort, sb[1] + comPortServerReply}
+			reply = append(reply, escapeIAC(value)...)
+			reply = append(reply, telnetIAC, telnetSE)
+			_, _ = conn.Write(reply)
+		}
+		return end + 2
+	}
+	return 2
+}
+
+func TestDialRFC2217Transport_Negotiation(t *testing.T) {
+	server := newFakeRFC2217Server(t)
+	defer server.ln.Close()
+
+	tr, err := DialRFC2217Transport(server.ln.Addr().String(), 2400, DefaultDialTimeout)
+	if err != nil {
+		t.Fatalf("DialRFC2217Transport() error = %v", err)
+	}
+	defer tr.Close()
+
+	tests := []struct {
+		name    string
+		command byte
+		want    []byte
+	}{
+		{name: "Baud rate 2400", command: comPortSetBaudrate, want: []byte{0x00, 0x00, 0x09, 0x60}},
+		{name: "8 data bits", command: comPortSetDatasize, want: []byte{8}},
+		{name: "Even parity", command: comPortSetParity, want: []byte{comPortParityEven}},
+		{name: "1 stop bit", command: comPortSetStopsize, want: []byte{comPortStopsize1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := server.setting(tt.command); !bytes.Equal(got, tt.want) {
+				t.Errorf("server setting %d = % X, want % X", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRFC2217Transport_SetBaudRate(t *testing.T) {
+	server := newFakeRFC2217Server(t)
+	defer server.ln.Close()
+
+	tr, err := DialRFC2217Transport(server.ln.Addr().String(), 2400, DefaultDialTimeout)
+	if err != nil {
+		t.Fatalf("DialRFC2217Transport() error = %v", err)
+	}
+	defer tr.Close()
+
+	rate, ok := CIField(CiFieldBaudrate9600).BaudRate()
+	if !ok {
+		t.Fatalf("BaudRate() ok = false, want true")
+	}
+	if err := tr.SetBaudRate(rate); err != nil {
+		t.Fatalf("SetBaudRate() error = %v", err)
+	}
+	if got := binary.BigEndian.Uint32(server.setting(comPortSetBaudrate)); got != 9600 {
+		t.Errorf("server baud rate = %v, want 9600", got)
+	}
+	if got, err := tr.BaudRate(); err != nil || got != 9600 {
+		t.Errorf("BaudRate() = %v, %v, want 9600", got, err)
+	}
+}
+
+func TestRFC2217Transport_SetBaudRate_Refused(t *testing.T) {
+	server := newFakeRFC2217Server(t)
+	defer server.ln.Close()
+	server.mu.Lock()
+	server.maxBaud = 9600
+	server.mu.Unlock()
+
+	tr, err := DialRFC2217Transport(server.ln.Addr().String(), 2400, DefaultDialTimeout)
+	if err != nil {
+		t.Fatalf("DialRFC2217Transport() error = %v", err)
+	}
+	defer tr.Close()
+
+	if err := tr.SetBaudRate(38400); !errors.Is(err, ErrUnsupportedBaudRate) {
+		t.Errorf("SetBaudRate(38400) error = %v, want %v", err, ErrUnsupportedBaudRate)
+	}
+	if got, err := tr.BaudRate(); err != nil || got != 9600 {
+		t.Errorf("BaudRate() = %v, %v, want 9600", got, err)
+	}
+}
+
+func TestRFC2217Transport_DataEscaping(t *testing.T) {
+	server := newFakeRFC2217Server(t)
+	defer server.ln.Close()
+
+	tr, err := OpenTransport("rfc2217://" + server.ln.Addr().String())
+	if err != nil {
+		t.Fatalf("OpenTransport() error = %v", err)
+	}
+	defer tr.Close()
+
+	frame := []byte{0x10, 0x7B, 0xFF, 0x7A, 0x16}
+	if _, err := tr.Write(frame); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	var got []byte
+	buf := make([]byte, 16)
+	for len(got) < len(frame) {
+		if err := tr.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+			t.Fatalf("SetReadDeadline() error = %v", err)
+		}
+		n, err := tr.Read(buf)
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		got = append(got, buf[:n]...)
+	}
+	if !bytes.Equal(got, frame) {
+		t.Errorf("Read() = % X, want % X", got, frame)
+	}
+}
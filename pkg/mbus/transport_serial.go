@@ -0,0 +1,131 @@
+package mbus
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/tarm/serial"
+)
+
+// serialPollInterval is the read timeout of the serial port. tarm/serial
+// cannot change the timeout of an open port, so the port polls in steps of
+// serialPollInterval and SerialTransport emulates read deadlines on top.
+const serialPollInterval = 100 * time.Millisecond
+
+// SerialTransport is a Transport over a local serial port.
+type SerialTransport struct {
+	name string
+
+	// portMu guards port and baud while SetBaudRate reopens the port
+	portMu sync.RWMutex
+	port   *serial.Port
+	baud   int
+
+	mu       sync.Mutex
+	deadline time.Time
+}
+
+// OpenSerialTransport opens a serial port with 8 data bits, even parity and
+// 1 stop bit at the given baud rate.
+func OpenSerialTransport(name string, baud int) (*SerialTransport, error) {
+	st := &SerialTransport{name: name, baud: baud}
+	port, err := st.open()
+	if err != nil {
+		return nil, err
+	}
+	st.port = port
+	return st, nil
+}
+
+func (st *SerialTransport) open() (*serial.Port, error) {
+	config := serial.Config{
+		Name:        st.name,
+		Baud:        st.baud,
+		Size:        8,
+		StopBits:    serial.Stop1,
+		Parity:      serial.ParityEven,
+		ReadTimeout: serialPollInterval,
+	}
+	return serial.OpenPort(&config)
+}
+
+// Write sends the frame to the serial port.
+func (st *SerialTransport) Write(frame []byte) (int, error) {
+	st.portMu.RLock()
+	defer st.portMu.RUnlock()
+	return st.port.Write(frame)
+}
+
+// Read waits for data until the read deadline.
+func (st *SerialTransport) Read(p []byte) (int, error) {
+	for {
+		st.portMu.RLock()
+		n, err := st.port.Read(p)
+		st.portMu.RUnlock()
+		if n > 0 {
+			return n, nil
+		}
+		// tarm/serial reports an elapsed poll interval as io.EOF
+		if err != nil && !errors.Is(err, io.EOF) {
+			return 0, err
+		}
+		st.mu.Lock()
+		deadline := st.deadline
+		st.mu.Unlock()
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			return 0, os.ErrDeadlineExceeded
+		}
+	}
+}
+
+// SetReadDeadline sets the deadline for future Read calls.
+func (st *SerialTransport) SetReadDeadline(t time.Time) error {
+	st.mu.Lock()
+	st.deadline = t
+	st.mu.Unlock()
+	return nil
+}
+
+// SetBaudRate reopens the serial port at the new baud rate. If the port does
+// not open at the new rate, it is reopened at the old one.
+func (st *SerialTransport) SetBaudRate(baud int) error {
+	st.portMu.Lock()
+	defer st.portMu.Unlock()
+
+	if err := st.port.Close(); err != nil {
+		return err
+	}
+	old := st.baud
+	st.baud = baud
+	port, err := st.open()
+	if err == nil {
+		st.port = port
+		return nil
+	}
+
+	st.baud = old
+	port, restoreErr := st.open()
+	if restoreErr != nil {
+		return errors.Join(err, fmt.Errorf("reopen at %d baud: %w", old, restoreErr))
+	}
+	st.port = port
+	return err
+}
+
+// BaudRate returns the baud rate the serial port is open at.
+func (st *SerialTransport) BaudRate() (int, error) {
+	st.portMu.RLock()
+	defer st.portMu.RUnlock()
+	return st.baud, nil
+}
+
+// Close closes the serial port.
+func (st *SerialTransport) Close() error {
+	st.portMu.RLock()
+	defer st.portMu.RUnlock()
+	return st.port.Close()
+}
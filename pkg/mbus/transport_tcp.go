@@ -0,0 +1,124 @@
+package mbus
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// DefaultDialTimeout is the connect timeout used by OpenTransport for tcp:// ports
+const DefaultDialTimeout = 5 * time.Second
+
+// TCPTransport is a Transport over a raw TCP socket of an Ethernet-to-M-Bus
+// level converter. A broken connection is re-established on the next Write.
+type TCPTransport struct {
+	addr        string
+	dialTimeout time.Duration
+
+	mu       sync.Mutex
+	conn     net.Conn
+	deadline time.Time
+	closed   bool
+}
+
+// DialTCPTransport connects to the level converter at addr (host:port).
+func DialTCPTransport(addr string, dialTimeout time.Duration) (*TCPTransport, error) {
+	tt := &TCPTransport{addr: addr, dialTimeout: dialTimeout}
+	if err := tt.Reconnect(); err != nil {
+		return nil, err
+	}
+	return tt, nil
+}
+
+// Reconnect closes the current connection, if any, and dials the converter again.
+func (tt *TCPTransport) Reconnect() error {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	return tt.reconnect()
+}
+
+func (tt *TCPTransport) reconnect() error {
+	if tt.closed {
+		return net.ErrClosed
+	}
+	if tt.conn != nil {
+		_ = tt.conn.Close()
+		tt.conn = nil
+	}
+	conn, err := net.DialTimeout("tcp", tt.addr, tt.dialTimeout)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetReadDeadline(tt.deadline); err != nil {
+		_ = conn.Close()
+		return err
+	}
+	tt.conn = conn
+	return nil
+}
+
+// Write sends the frame. If the connection was lost, it reconnects and
+// sends the frame once more.
+func (tt *TCPTransport) Write(frame []byte) (int, error) {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
+	if tt.conn != nil {
+		n, err := tt.conn.Write(frame)
+		if err == nil {
+			return n, nil
+		}
+	}
+	if err := tt.reconnect(); err != nil {
+		return 0, err
+	}
+	return tt.conn.Write(frame)
+}
+
+// Read reads from the connection until the read deadline. When the
+// converter closes the connection, the next Write reconnects.
+func (tt *TCPTransport) Read(p []byte) (int, error) {
+	tt.mu.Lock()
+	conn := tt.conn
+	tt.mu.Unlock()
+	if conn == nil {
+		return 0, errors.New("tcp transport is not connected")
+	}
+
+	n, err := conn.Read(p)
+	if err != nil && !isTimeout(err) {
+		tt.mu.Lock()
+		if tt.conn == conn {
+			_ = conn.Close()
+			tt.conn = nil
+		}
+		tt.mu.Unlock()
+	}
+	return n, err
+}
+
+// SetReadDeadline sets the deadline for future Read calls. The deadline
+// is kept across reconnects.
+func (tt *TCPTransport) SetReadDeadline(t time.Time) error {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	tt.deadline = t
+	if tt.conn == nil {
+		return nil
+	}
+	return tt.conn.SetReadDeadline(t)
+}
+
+// Close closes the connection.
+func (tt *TCPTransport) Close() error {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	tt.closed = true
+	if tt.conn == nil {
+		return nil
+	}
+	err := tt.conn.Close()
+	tt.conn = nil
+	return err
+}
@@ -0,0 +1,87 @@
+package mbus
+
+import (
+	"net"
+	"testing"
+)
+
+// fakeConverter accepts connections on a local listener and serves each one with
+// fakeSlave. After closeAfter requests a connection is dropped by the converter.
+func fakeConverter(t *testing.T, closeAfter int) net.Listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			requests := 0
+			fakeSlave(t, conn, func(request []byte) []byte {
+				requests++
+				if closeAfter > 0 && requests > closeAfter {
+					conn.Close()
+					return nil
+				}
+				return []byte{FRAME_ACK_START}
+			})
+		}
+	}()
+	return ln
+}
+
+func TestOpenTransport_TCP(t *testing.T) {
+	ln := fakeConverter(t, 0)
+	defer ln.Close()
+
+	tr, err := OpenTransport("tcp://" + ln.Addr().String())
+	if err != nil {
+		t.Fatalf("OpenTransport() error = %v", err)
+	}
+	defer tr.Close()
+
+	if _, ok := tr.(*TCPTransport); !ok {
+		t.Fatalf("OpenTransport() = %T, want *TCPTransport", tr)
+	}
+	if ps := PingWith(tr, 1); !ps.State {
+		t.Errorf("PingWith() state = false, want true (error: %s)", ps.Error)
+	}
+}
+
+func TestTCPTransport_Reconnect(t *testing.T) {
+	ln := fakeConverter(t, 1)
+	defer ln.Close()
+
+	tr, err := DialTCPTransport(ln.Addr().String(), DefaultDialTimeout)
+	if err != nil {
+		t.Fatalf("DialTCPTransport() error = %v", err)
+	}
+	defer tr.Close()
+
+	if ps := PingWith(tr, 1); !ps.State {
+		t.Fatalf("first PingWith() state = false, want true (error: %s)", ps.Error)
+	}
+	// The converter dropped the connection after the first request
+	if ps := PingWith(tr, 1); ps.State {
+		t.Fatalf("second PingWith() state = true, want false")
+	}
+	if ps := PingWith(tr, 1); !ps.State {
+		t.Errorf("PingWith() after reconnect state = false, want true (error: %s)", ps.Error)
+	}
+}
+
+func TestDialTCPTransport_Refused(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	if _, err := DialTCPTransport(addr, DefaultDialTimeout); err == nil {
+		t.Errorf("DialTCPTransport() error = nil, want connection error")
+	}
+}
@@ -0,0 +1,205 @@
+package mbus
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrInvalidRecord is returned for a data record that can not be encoded
+var ErrInvalidRecord = errors.New("invalid data record")
+
+// RecordAction is the object action VIFE of a record written by the master
+type RecordAction byte
+
+// Object actions (master to slave). The zero RecordAction sends no action VIFE,
+// slaves treat such a record as ActionWrite.
+const (
+	ActionWrite             RecordAction = 0x70 // write (replace)
+	ActionAdd               RecordAction = 0x71 // add value
+	ActionSubtract          RecordAction = 0x72 // subtract value
+	ActionOr                RecordAction = 0x73 // set bits
+	ActionAnd               RecordAction = 0x74 // AND
+	ActionXor               RecordAction = 0x75 // toggle bits
+	ActionAndNot            RecordAction = 0x76 // clear bits
+	ActionClear             RecordAction = 0x77 // clear, e.g. reset a maximum value
+	ActionAddEntry          RecordAction = 0x78
+	ActionDeleteEntry       RecordAction = 0x79
+	ActionFreeze            RecordAction = 0x7B // freeze data to the storage number
+	ActionAddToReadout      RecordAction = 0x7C // add the record to the readout list
+	ActionDeleteFromReadout RecordAction = 0x7D // delete the record from the readout list
+)
+
+// Data field codes of the DIF (bits 1-4), see DIFFieldLengthTable
+const (
+	DataFieldNoData   byte = 0b0000
+	DataFieldInt8     byte = 0b0001
+	DataFieldInt16    byte = 0b0010
+	DataFieldInt24    byte = 0b0011
+	DataFieldInt32    byte = 0b0100
+	DataFieldReal32   byte = 0b0101
+	DataFieldInt48    byte = 0b0110
+	DataFieldInt64    byte = 0b0111
+	DataFieldBCD2     byte = 0b1001
+	DataFieldBCD4     byte = 0b1010
+	DataFieldBCD6     byte = 0b1011
+	DataFieldBCD8     byte = 0b1100
+	DataFieldVariable byte = 0b1101
+	DataFieldBCD12    byte = 0b1110
+)
+
+// RecordWrite is one data record the master writes to the slave with SND_UD
+type RecordWrite struct {
+	Action RecordAction
+	// Function instantaneous, maximum, minimum or value during error (DIF bits 5-6)
+	Function      DIFFieldTypeOfData
+	StorageNumber uint
+	Tariff        uint
+	SubUnit       uint
+	// VIF without extension bit, 0x7B and 0x7D select the extension tables in VIFE[0]
+	VIF byte
+	// VIFE without extension bits and without the action
+	VIFE []byte
+	// DataField is the data field code of the DIF, one of the DataField* constants
+	DataField byte
+	// Value in the unit of VifFields / VifVifeFdFields, e.g. 12.5 for 12.5 m^3.
+	// It is divided by the exponent of the VIF before it is encoded.
+	Value float64
+	// Raw is sent as data instead of Value, e.g. a time point or text for DataFieldVariable
+	Raw []byte
+}
+
+// Bytes encodes the record: DIF, DIFE, VIF, VIFE with the action and the data
+func (rw RecordWrite) Bytes() ([]byte, error) {
+	if rw.Function > VALUE_DURING_ERROR {
+		return nil, fmt.Errorf("%w: function %d", ErrInvalidRecord, rw.Function)
+	}
+	record, ok := DIFFieldLengthTable[rw.DataField]
+	if !ok || rw.DataField == difSelectionForReadout || rw.DataField == 0x0F {
+		return nil, fmt.Errorf("%w: data field 0x%X", ErrInvalidRecord, rw.DataField)
+	}
+
+	vifes := append([]byte{}, rw.VIFE...)
+	if rw.Action != 0 {
+		vifes = append(vifes, byte(rw.Action))
+	}
+	dif := rw.DataField | byte(rw.Function)<<4
+	header, err := encodeRecordHeader(dif, rw.StorageNumber, rw.Tariff, rw.SubUnit, rw.VIF, vifes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRecord, err)
+	}
+
+	var data []byte
+	switch {
+	case rw.DataField == DataFieldVariable:
+		// LVAR 0x00-0xBF is the length of an ASCII string
+		if len(rw.Raw) > 0xBF {
+			return nil, fmt.Errorf("%w: variable data longer than %d bytes", ErrInvalidRecord, 0xBF)
+		}
+		data = append([]byte{byte(len(rw.Raw))}, rw.Raw...)
+	case rw.Raw != nil:
+		if len(rw.Raw) != record.length {
+			return nil, fmt.Errorf("%w: %s needs %d bytes and not %d", ErrInvalidRecord, record.name, record.length, len(rw.Raw))
+		}
+		data = rw.Raw
+	default:
+		data, err = encodeRecordValue(rw.DataField, record.length, rw.Value/vifExponent(rw.VIF, rw.VIFE))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return append(header, data...), nil
+}
+
+// vifExponent returns the exponent of the VIF from VifFields, or of the first VIFE
+// from VifVifeFdFields / VifVifeFbFields for the extension VIFs 0x7D and 0x7B.
+// Unknown VIFs have exponent 1.
+func vifExponent(vif byte, vifes []byte) float64 {
+	var record VIFFieldsRecord
+	var ok bool
+	switch vif & 0x7F {
+	case 0x7D:
+		if len(vifes) > 0 {
+			record, ok = VifVifeFdFields[vifes[0]&0x7F]
+		}
+	case 0x7B:
+		if len(vifes) > 0 {
+			record, ok = VifVifeFbFields[vifes[0]&0x7F]
+		}
+	default:
+		record, ok = VifFields[vif&0x7F]
+	}
+	if !ok || record.Exponent == 0 {
+		return 1
+	}
+	return record.Exponent
+}
+
+// encodeRecordValue encodes the scaled value as integer, BCD or real of the data field
+func encodeRecordValue(dataField byte, length int, value float64) ([]byte, error) {
+	data := make([]byte, length)
+	switch dataField {
+	case DataFieldNoData:
+		return data, nil
+	case DataFieldReal32:
+		binary.LittleEndian.PutUint32(data, math.Float32bits(float32(value)))
+		return data, nil
+	case DataFieldBCD2, DataFieldBCD4, DataFieldBCD6, DataFieldBCD8, DataFieldBCD12:
+		n := math.Round(value)
+		if n < 0 || n >= math.Pow(10, float64(2*length)) {
+			return nil, fmt.Errorf("%w: %v does not fit into %d BCD digits", ErrInvalidRecord, n, 2*length)
+		}
+		digits := uint64(n)
+		for i := range data {
+			data[i] = byte(digits%10) | byte(digits/10%10)<<4
+			digits /= 100
+		}
+		return data, nil
+	}
+
+	n := math.Round(value)
+	limit := math.Pow(2, float64(8*length-1))
+	if n < -limit || n >= limit {
+		return nil, fmt.Errorf("%w: %v does not fit into %d byte integer", ErrInvalidRecord, n, length)
+	}
+	v := uint64(int64(n))
+	for i := range data {
+		data[i] = byte(v >> (8 * i))
+	}
+	return data, nil
+}
+
+// COMMAND_WRITE_RECORDS Write the data records to the slave with SND_UD CI 0x51
+func COMMAND_WRITE_RECORDS(deviceAddress uint, records []RecordWrite) ([]byte, error) {
+	data, err := recordData(records)
+	if err != nil {
+		return nil, err
+	}
+	return COMMAND_SND_UD(deviceAddress, CiFieldDataSend, data)
+}
+
+// recordData returns the encoded records one after the other
+func recordData(records []RecordWrite) ([]byte, error) {
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: no record", ErrInvalidRecord)
+	}
+	var data []byte
+	for _, record := range records {
+		encoded, err := record.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, encoded...)
+	}
+	return data, nil
+}
+
+// writeRecords sends the data records and waits for the ACK
+func writeRecords(t Transport, deviceAddress uint, records []RecordWrite, timing Timing) error {
+	data, err := recordData(records)
+	if err != nil {
+		return err
+	}
+	return sendUserData(t, deviceAddress, CiFieldDataSend, data, timing)
+}
@@ -0,0 +1,113 @@
+package mbus
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestRecordWrite_Bytes(t *testing.T) {
+	tests := []struct {
+		name    string
+		record  RecordWrite
+		want    []byte
+		wantErr error
+	}{
+		{
+			name:   "Volume in m^3",
+			record: RecordWrite{VIF: 0x13, DataField: DataFieldInt32, Value: 12.5},
+			want:   []byte{0x04, 0x13, 0xD4, 0x30, 0x00, 0x00},
+		},
+		{
+			name:   "Subtract volume",
+			record: RecordWrite{Action: ActionAdd, VIF: 0x13, DataField: DataFieldInt16, Value: -0.5},
+			want:   []byte{0x02, 0x93, 0x71, 0x0C, 0xFE},
+		},
+		{
+			name:   "Clear maximum power",
+			record: RecordWrite{Action: ActionClear, Function: MAXIMUM, VIF: 0x2B, DataField: DataFieldNoData},
+			want:   []byte{0x10, 0xAB, 0x77},
+		},
+		{
+			name:   "Fabrication number of tariff 1",
+			record: RecordWrite{Tariff: 1, VIF: 0x78, DataField: DataFieldBCD8, Value: 12345678},
+			want:   []byte{0x8C, 0x10, 0x78, 0x78, 0x56, 0x34, 0x12},
+		},
+		{
+			name:   "Customer text",
+			record: RecordWrite{Action: ActionWrite, VIF: 0x7D, VIFE: []byte{0x11}, DataField: DataFieldVariable, Raw: []byte("AB")},
+			want:   []byte{0x0D, 0xFD, 0x91, 0x70, 0x02, 0x41, 0x42},
+		},
+		{
+			name:   "Raw time point",
+			record: RecordWrite{VIF: 0x6D, DataField: DataFieldInt32, Raw: []byte{0x19, 0x0F, 0x8A, 0x17}},
+			want:   []byte{0x04, 0x6D, 0x19, 0x0F, 0x8A, 0x17},
+		},
+		{
+			name:    "Raw with wrong length",
+			record:  RecordWrite{VIF: 0x6D, DataField: DataFieldInt32, Raw: []byte{0x19}},
+			wantErr: ErrInvalidRecord,
+		},
+		{
+			name:    "Integer overflow",
+			record:  RecordWrite{VIF: 0x13, DataField: DataFieldInt8, Value: 1},
+			wantErr: ErrInvalidRecord,
+		},
+		{
+			name:    "BCD overflow",
+			record:  RecordWrite{VIF: 0x78, DataField: DataFieldBCD2, Value: 100},
+			wantErr: ErrInvalidRecord,
+		},
+		{
+			name:    "Storage number too large",
+			record:  RecordWrite{VIF: 0x13, DataField: DataFieldInt8, StorageNumber: 1 << 42},
+			wantErr: ErrInvalidRecord,
+		},
+		{
+			name:    "Selection data field",
+			record:  RecordWrite{VIF: 0x13, DataField: 0x08},
+			wantErr: ErrInvalidRecord,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.record.Bytes()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Bytes() error = %v, want %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("Bytes() = % X, want % X", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBus_WriteRecords(t *testing.T) {
+	tr, slave := NewPipeTransport()
+	written := make(chan []byte, 1)
+	fakeSlave(t, slave, func(request []byte) []byte {
+		if request[0] == FRAME_LONG_START && request[5] == 1 && CIField(request[6]) == CiFieldDataSend {
+			written <- request[7 : len(request)-2]
+			return []byte{FRAME_ACK_START}
+		}
+		return nil
+	})
+	bus := NewBus(tr)
+	defer bus.Close()
+
+	records := []RecordWrite{
+		{Action: ActionClear, Function: MAXIMUM, VIF: 0x2B, DataField: DataFieldNoData},
+		{VIF: 0x7A, DataField: DataFieldInt8, Value: 5},
+	}
+	if err := bus.WriteRecords(1, records); err != nil {
+		t.Fatalf("WriteRecords() error = %v", err)
+	}
+	if got, want := <-written, []byte{0x10, 0xAB, 0x77, 0x01, 0x7A, 0x05}; !bytes.Equal(got, want) {
+		t.Errorf("slave received % X, want % X", got, want)
+	}
+
+	if err := bus.WriteRecords(2, records); !errors.Is(err, ErrNoAck) {
+		t.Errorf("WriteRecords() at address 2 error = %v, want %v", err, ErrNoAck)
+	}
+}